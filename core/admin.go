@@ -116,8 +116,14 @@ func GetAllHandlers(hoverfly *Hoverfly) []handlers.AdminHandler {
 	list = append(list, &v2.HoverflyHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyDestinationHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyModeHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyHashingHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyMiddlewareHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyRoutesHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyPassthroughHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyRedactionHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyUsageHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.ScenariosHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.DiffHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.SimulationHandler{Hoverfly: hoverfly})
 
 	return list
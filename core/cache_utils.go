@@ -1,12 +1,29 @@
 package hoverfly
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/cache"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/models"
 )
 
-func rebuildHashes(db cache.Cache, webserver bool) {
+// hashConfigurationKey is where the hash configuration is kept in the metadata
+// cache, so that pairs are not rehashed with the defaults on a restart
+const hashConfigurationKey = "hash_configuration"
+
+// redactionKey is where the redaction is kept in the metadata cache, as requests
+// are hashed once redacted they could not be matched on a restart without it
+const redactionKey = "redaction"
+
+// rebuildHashes moves pairs onto the keys they have with the given hash configuration
+// and redaction. When different pairs would end up on the same key nothing is moved
+// and an error naming the colliding keys is returned, rather than losing any of them.
+func rebuildHashes(db cache.Cache, webserver bool, hashConfiguration *models.HashConfiguration, redaction *models.Redaction) error {
 	log.Info("Checking if keys in cache need rehashing")
 
 	entries, err := db.GetAllEntries()
@@ -14,6 +31,11 @@ func rebuildHashes(db cache.Cache, webserver bool) {
 		log.Fatal("Unable to read from BoltDB cache")
 	}
 
+	// keys are all worked out before the cache is touched, otherwise a pair which
+	// is moved onto the old key of another pair would be deleted along with it
+	newKeys := make(map[string]string)
+	oldKeys := make(map[string][]string)
+
 	for key, bytes := range entries {
 		pair, err := models.NewRequestResponsePairFromBytes(bytes)
 		if err != nil {
@@ -22,17 +44,136 @@ func rebuildHashes(db cache.Cache, webserver bool) {
 				"value": string(bytes),
 				"key":   key,
 			}).Error("Failed to decode payload")
+			continue
 		}
-		var newKey string
-		if webserver {
-			newKey = pair.IdWithoutHost()
-		} else {
-			newKey = pair.Id()
+		pair.Request = redaction.RedactRequest(pair.Request)
+		newKey := pair.Key(hashConfiguration, !webserver)
+
+		newKeys[key] = newKey
+		oldKeys[newKey] = append(oldKeys[newKey], key)
+	}
+
+	var collisions []string
+	for newKey, keys := range oldKeys {
+		for _, key := range keys[1:] {
+			if string(entries[key]) != string(entries[keys[0]]) {
+				sort.Strings(keys)
+				collisions = append(collisions, fmt.Sprintf("%s (from %s)", newKey, strings.Join(keys, ", ")))
+				break
+			}
 		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return fmt.Errorf("Different recorded requests would have the same key: %s", strings.Join(collisions, "; "))
+	}
+
+	rehashed := make(map[string][]byte)
+	for key, newKey := range newKeys {
+		if key != newKey {
+			rehashed[newKey] = entries[key]
+		}
+	}
 
+	for key, newKey := range newKeys {
 		if key != newKey {
 			db.Delete([]byte(key))
-			db.Set([]byte(newKey), bytes)
 		}
 	}
+
+	for key, bytes := range rehashed {
+		db.Set([]byte(key), bytes)
+	}
+
+	if len(rehashed) > 0 {
+		log.WithFields(log.Fields{
+			"rehashed": len(rehashed),
+		}).Info("Keys in cache have been rehashed")
+	}
+	return nil
+}
+
+// loadHashConfiguration reads the hash configuration saved by saveHashConfiguration,
+// returning nil when there is none
+func loadHashConfiguration(metadataCache cache.Cache) *models.HashConfiguration {
+	if metadataCache == nil {
+		return nil
+	}
+
+	bytes, err := metadataCache.Get([]byte(hashConfigurationKey))
+	if err != nil || len(bytes) == 0 {
+		return nil
+	}
+
+	var view v2.HashConfigurationView
+	if err := json.Unmarshal(bytes, &view); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"value": string(bytes),
+		}).Error("Failed to decode saved hash configuration")
+		return nil
+	}
+
+	hashConfiguration, err := models.NewHashConfigurationFromView(view)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Saved hash configuration is not valid")
+		return nil
+	}
+	return hashConfiguration
+}
+
+func saveHashConfiguration(metadataCache cache.Cache, hashConfiguration *models.HashConfiguration) error {
+	if metadataCache == nil {
+		return nil
+	}
+
+	bytes, err := json.Marshal(hashConfiguration.ConvertToHashConfigurationView())
+	if err != nil {
+		return err
+	}
+	return metadataCache.Set([]byte(hashConfigurationKey), bytes)
+}
+
+// loadRedaction reads the redaction saved by saveRedaction, returning nil when there is none
+func loadRedaction(metadataCache cache.Cache) *models.Redaction {
+	if metadataCache == nil {
+		return nil
+	}
+
+	bytes, err := metadataCache.Get([]byte(redactionKey))
+	if err != nil || len(bytes) == 0 {
+		return nil
+	}
+
+	var view v2.RedactionView
+	if err := json.Unmarshal(bytes, &view); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"value": string(bytes),
+		}).Error("Failed to decode saved redaction")
+		return nil
+	}
+
+	redaction, err := models.NewRedactionFromView(view)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Saved redaction is not valid")
+		return nil
+	}
+	return redaction
+}
+
+func saveRedaction(metadataCache cache.Cache, redaction *models.Redaction) error {
+	if metadataCache == nil {
+		return nil
+	}
+
+	bytes, err := json.Marshal(redaction.ConvertToRedactionView())
+	if err != nil {
+		return err
+	}
+	return metadataCache.Set([]byte(redactionKey), bytes)
 }
@@ -27,7 +27,7 @@ func Test_rebuildHashes_whenDataIsHashedForAProxy_andStillAProxy_keysAreNotChang
 
 	db.Set([]byte(pair.Id()), pairBytes)
 
-	rebuildHashes(db, webserver)
+	rebuildHashes(db, webserver, nil, nil)
 
 	result, err := db.Get([]byte(pair.Id()))
 
@@ -55,7 +55,7 @@ func Test_rebuildHashes_whenDataIsHashedForAWebserver_andStillAWebserver_keysAre
 
 	db.Set([]byte(pair.IdWithoutHost()), pairBytes)
 
-	rebuildHashes(db, webserver)
+	rebuildHashes(db, webserver, nil, nil)
 
 	result, err := db.Get([]byte(pair.IdWithoutHost()))
 
@@ -83,10 +83,146 @@ func Test_rebuildHashes_whenDataIsHashedForAProxy_andIsNowAWebserver_keysAreChan
 
 	db.Set([]byte(pair.Id()), pairBytes)
 
-	rebuildHashes(db, webserver)
+	rebuildHashes(db, webserver, nil, nil)
 
 	result, err := db.Get([]byte(pair.IdWithoutHost()))
 
 	Expect(err).To(BeNil())
 	Expect(result).To(Equal(pairBytes))
 }
+
+func Test_rebuildHashes_whenTheNewKeyOfAPairIsTheOldKeyOfAnother_bothPairsAreKept(t *testing.T) {
+	RegisterTestingT(t)
+	webserver := false
+
+	db := cache.NewInMemoryCache()
+
+	firstPair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Path:        "/first",
+			Destination: "a-host.com",
+		},
+		Response: models.ResponseDetails{
+			Body: "first",
+		},
+	}
+
+	secondPair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Path:        "/second",
+			Destination: "a-host.com",
+		},
+		Response: models.ResponseDetails{
+			Body: "second",
+		},
+	}
+
+	firstPairBytes, _ := firstPair.Encode()
+	secondPairBytes, _ := secondPair.Encode()
+
+	db.Set([]byte("stale-key"), firstPairBytes)
+	db.Set([]byte(firstPair.Id()), secondPairBytes)
+
+	rebuildHashes(db, webserver, nil, nil)
+
+	result, err := db.Get([]byte(firstPair.Id()))
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(firstPairBytes))
+
+	result, err = db.Get([]byte(secondPair.Id()))
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(secondPairBytes))
+
+	count, _ := db.RecordsCount()
+	Expect(count).To(Equal(2))
+}
+
+func Test_rebuildHashes_whenDifferentPairsWouldHaveTheSameKey_returnsAnErrorAndLeavesTheCache(t *testing.T) {
+	RegisterTestingT(t)
+	webserver := false
+
+	db := cache.NewInMemoryCache()
+
+	firstPair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Path:        "/users",
+			Destination: "a-host.com",
+			Query:       "_=1",
+		},
+		Response: models.ResponseDetails{
+			Body: "first",
+		},
+	}
+
+	secondPair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Path:        "/users",
+			Destination: "a-host.com",
+			Query:       "_=2",
+		},
+		Response: models.ResponseDetails{
+			Body: "second",
+		},
+	}
+
+	firstPairBytes, _ := firstPair.Encode()
+	secondPairBytes, _ := secondPair.Encode()
+
+	db.Set([]byte(firstPair.Id()), firstPairBytes)
+	db.Set([]byte(secondPair.Id()), secondPairBytes)
+
+	err := rebuildHashes(db, webserver, &models.HashConfiguration{ExcludeQueryParams: []string{"_"}}, nil)
+	Expect(err).ToNot(BeNil())
+
+	result, err := db.Get([]byte(firstPair.Id()))
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(firstPairBytes))
+
+	result, err = db.Get([]byte(secondPair.Id()))
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(secondPairBytes))
+}
+
+func Test_loadHashConfiguration_returnsTheSavedHashConfiguration(t *testing.T) {
+	RegisterTestingT(t)
+
+	metadataCache := cache.NewInMemoryCache()
+
+	Expect(loadHashConfiguration(metadataCache)).To(BeNil())
+
+	err := saveHashConfiguration(metadataCache, &models.HashConfiguration{
+		IncludeHeaders:     []string{"X-Tenant"},
+		ExcludeQueryParams: []string{"_"},
+	})
+	Expect(err).To(BeNil())
+
+	hashConfiguration := loadHashConfiguration(metadataCache)
+	Expect(hashConfiguration).ToNot(BeNil())
+	Expect(hashConfiguration.IncludeHeaders).To(ConsistOf("X-Tenant"))
+	Expect(hashConfiguration.ExcludeQueryParams).To(ConsistOf("_"))
+}
+
+func Test_loadRedaction_returnsTheSavedRedaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	metadataCache := cache.NewInMemoryCache()
+
+	Expect(loadRedaction(metadataCache)).To(BeNil())
+
+	err := saveRedaction(metadataCache, &models.Redaction{
+		Mask:          "***",
+		MaskHeaders:   []string{"Authorization"},
+		MaskBodyPaths: []string{"$.password"},
+		BodyReplacements: []models.BodyReplacement{
+			{Pattern: `\d{16}`, Replacement: "[card]"},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	redaction := loadRedaction(metadataCache)
+	Expect(redaction).ToNot(BeNil())
+	Expect(redaction.Mask).To(Equal("***"))
+	Expect(redaction.MaskHeaders).To(ConsistOf("Authorization"))
+	Expect(redaction.MaskBodyPaths).To(ConsistOf("$.password"))
+	Expect(redaction.RedactRequest(models.RequestDetails{Body: "4111111111111111"}).Body).To(Equal("[card]"))
+}
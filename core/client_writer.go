@@ -0,0 +1,123 @@
+package hoverfly
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+type clientWriterKey struct{}
+
+// clientWriter lets Hoverfly take over the client connection of a request to
+// inject a fault, or flush each write of a streamed response. Once hijacked,
+// whatever goproxy still writes for the response is discarded.
+type clientWriter struct {
+	http.ResponseWriter
+	hijacked  bool
+	streaming bool
+	// trailer is sent once the response body has been written
+	trailer http.Header
+	// headerOrder is the order and casing of the recorded response headers, the
+	// casing of which is kept
+	headerOrder []string
+	req         *http.Request
+}
+
+func (this *clientWriter) WriteHeader(status int) {
+	if this.hijacked {
+		return
+	}
+
+	applyHeaderCasing(this.Header(), this.headerOrder)
+	this.ResponseWriter.WriteHeader(status)
+}
+
+func (this *clientWriter) Write(b []byte) (int, error) {
+	if this.hijacked {
+		return 0, http.ErrHijacked
+	}
+
+	n, err := this.ResponseWriter.Write(b)
+	if this.streaming {
+		this.Flush()
+	}
+	return n, err
+}
+
+func (this *clientWriter) Flush() {
+	if flusher, ok := this.ResponseWriter.(http.Flusher); ok && !this.hijacked {
+		flusher.Flush()
+	}
+}
+
+func (this *clientWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := this.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("Connection cannot be hijacked")
+	}
+
+	conn, buffer, err := hijacker.Hijack()
+	this.hijacked = err == nil
+	return conn, buffer, err
+}
+
+// withClientWriter passes the response writer of each request on through the
+// request context, as goproxy does not hand it to its request handlers
+func withClientWriter(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer := &clientWriter{ResponseWriter: w, req: r}
+		handler.ServeHTTP(writer, r.WithContext(context.WithValue(r.Context(), clientWriterKey{}, writer)))
+
+		// the headers of the next request on the connection follow this one's body
+		if conn := requestHeaderConn(r); conn != nil && r.ProtoMajor == 1 {
+			conn.record()
+		}
+
+		if !writer.hijacked {
+			writeTrailer(w, writer.trailer)
+		}
+	})
+}
+
+func clientWriterFor(req *http.Request) *clientWriter {
+	writer, _ := req.Context().Value(clientWriterKey{}).(*clientWriter)
+	return writer
+}
+
+// isStreamed returns true for bodies which are sent to the client as they are read
+func isStreamed(body io.ReadCloser) bool {
+	switch body.(type) {
+	case *models.ThrottledBody, *models.EventStreamBody, *recordingBody:
+		return true
+	}
+	return false
+}
+
+// streamBody writes the body to the client as it is read, flushing each write
+func streamBody(w http.ResponseWriter, body io.Reader) error {
+	flusher, _ := w.(http.Flusher)
+	buffer := make([]byte, 32*1024)
+
+	for {
+		n, err := body.Read(buffer)
+		if n > 0 {
+			if _, writeErr := w.Write(buffer[:n]); writeErr != nil {
+				return writeErr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -68,6 +68,11 @@ var (
 	dev         = flag.Bool("dev", false, "supply -dev flag to serve directly from ./static/dist instead from statik binary")
 	destination = flag.String("destination", ".", "destination URI to catch")
 	webserver   = flag.Bool("webserver", false, "start Hoverfly in webserver mode (simulate mode)")
+	spy         = flag.Bool("spy", false, "start Hoverfly in spy mode - simulates requests which match and forwards the rest to their destination")
+	spyCapture  = flag.Bool("spy-capture", false, "capture the requests spy mode forwards to their destination")
+
+	captureSequence = flag.String("capture-sequence", "", "record repeated requests in capture mode as a sequence of responses - 'loop' to replay from the first response after the last one or 'stick' to keep replaying the last one")
+	streamSpeed     = flag.Float64("stream-speed", 0, "replay the events of streamed responses this many times faster than they were captured (defaults to 1)")
 
 	addNew      = flag.Bool("add", false, "add new user '-add -username hfadmin -password hfpass'")
 	addUser     = flag.String("username", "", "username for new user")
@@ -151,7 +156,7 @@ func init() {
 func main() {
 	log.SetFormatter(&log.JSONFormatter{})
 	flag.Var(&importFlags, "import", "import from file or from URL (i.e. '-import my_service.json' or '-import http://mypage.com/service_x.json'")
-	flag.Var(&destinationFlags, "dest", "specify which hosts to process (i.e. '-dest fooservice.org -dest barservice.org -dest catservice.org') - other hosts will be ignored will passthrough'")
+	flag.Var(&destinationFlags, "dest", "specify which hosts to process (i.e. '-dest fooservice.org -dest barservice.org -dest catservice.org') - other hosts will be ignored will passthrough'. A host can be given its own mode and middleware (i.e. '-dest fooservice.org=capture -dest barservice.org=modify,./middleware.py')")
 	flag.Parse()
 
 	if *version {
@@ -211,6 +216,24 @@ func main() {
 	// overriding default middleware setting
 	cfg.Middleware = *middleware
 
+	if *captureSequence != "" {
+		cfg.CaptureSequence = *captureSequence
+	}
+	if cfg.CaptureSequence != "" && cfg.CaptureSequence != hv.CaptureSequenceLoop && cfg.CaptureSequence != hv.CaptureSequenceStick {
+		log.Fatalf("Capture sequence must be either '%s' or '%s'", hv.CaptureSequenceLoop, hv.CaptureSequenceStick)
+	}
+
+	if *spyCapture {
+		cfg.SpyCapture = true
+	}
+
+	if *streamSpeed < 0 {
+		log.Fatal("Stream speed must be greater than 0")
+	}
+	if *streamSpeed > 0 {
+		cfg.StreamSpeed = *streamSpeed
+	}
+
 	mode := getInitialMode(cfg)
 
 	// setting mode
@@ -228,7 +251,12 @@ func main() {
 	}
 
 	if len(destinationFlags) > 0 {
-		cfg.Destination = strings.Join(destinationFlags[:], "|")
+		destinations, routes, err := parseDestinationFlags(destinationFlags)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		cfg.Destination = strings.Join(destinations, "|")
+		cfg.Routes = routes
 
 	} else {
 		//  setting destination regexp
@@ -394,6 +422,33 @@ func createSuperUser(h *hv.Hoverfly) {
 	}
 }
 
+// parseDestinationFlags splits each destination from the mode and middleware it
+// may be given, as host=mode or host=mode,middleware
+func parseDestinationFlags(flags arrayFlags) ([]string, []hv.Route, error) {
+	var destinations []string
+	var routes []hv.Route
+	for _, value := range flags {
+		parts := strings.SplitN(value, "=", 2)
+		destinations = append(destinations, parts[0])
+		if len(parts) == 1 {
+			continue
+		}
+
+		settings := strings.SplitN(parts[1], ",", 2)
+		middleware := ""
+		if len(settings) == 2 {
+			middleware = settings[1]
+		}
+
+		route, err := hv.NewRoute(parts[0], settings[0], middleware)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes = append(routes, route)
+	}
+	return destinations, routes, nil
+}
+
 func getInitialMode(cfg *hv.Configuration) string {
 	if *webserver {
 		return hv.SimulateMode
@@ -401,7 +456,7 @@ func getInitialMode(cfg *hv.Configuration) string {
 
 	if *capture {
 		// checking whether user supplied other modes
-		if *synthesize == true || *modify == true {
+		if *synthesize == true || *modify == true || *spy == true {
 			log.Fatal("Two or more modes supplied, check your flags")
 		}
 
@@ -413,7 +468,7 @@ func getInitialMode(cfg *hv.Configuration) string {
 			log.Fatal("Synthesize mode chosen although middleware not supplied")
 		}
 
-		if *capture == true || *modify == true {
+		if *capture == true || *modify == true || *spy == true {
 			log.Fatal("Two or more modes supplied, check your flags")
 		}
 
@@ -424,11 +479,14 @@ func getInitialMode(cfg *hv.Configuration) string {
 			log.Fatal("Modify mode chosen although middleware not supplied")
 		}
 
-		if *capture == true || *synthesize == true {
+		if *capture == true || *synthesize == true || *spy == true {
 			log.Fatal("Two or more modes supplied, check your flags")
 		}
 
 		return hv.ModifyMode
+
+	} else if *spy {
+		return hv.SpyMode
 	}
 
 	return hv.SimulateMode
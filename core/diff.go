@@ -0,0 +1,89 @@
+package hoverfly
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/templating"
+)
+
+// diffRequest forwards a request to its destination and records how the response
+// differs from the one in the simulation. The real response is returned. Looking
+// up the simulated response leaves scenarios and sequences in the state they are.
+func (hf *Hoverfly) diffRequest(req *http.Request, requestDetails models.RequestDetails) (*http.Response, error) {
+	_, resp, err := hf.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, matchErr := hf.RequestMatcher.PeekResponse(&requestDetails)
+	if matchErr != nil {
+		log.WithFields(log.Fields{
+			"mode":        DiffMode,
+			"path":        requestDetails.Path,
+			"method":      requestDetails.Method,
+			"destination": requestDetails.Destination,
+			"error":       matchErr.Description,
+		}).Warn("No simulated response to compare with")
+		return resp, nil
+	}
+
+	if expected.Templated {
+		rendered, err := templating.RenderResponse(*expected, requestDetails)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"mode":        DiffMode,
+				"path":        requestDetails.Path,
+				"method":      requestDetails.Method,
+				"destination": requestDetails.Destination,
+				"error":       err.Error(),
+			}).Warn("Failed to render simulated response to compare with")
+			return resp, nil
+		}
+		expected = &rendered
+	}
+
+	// compared as it would have been captured
+	actual := models.ResponseDetails{
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+	}
+
+	var differences []models.Difference
+	if isStreamingResponse(resp) {
+		// a stream is passed on as it arrives, as its body may never end
+		differences = models.DiffStatusAndHeaders(*expected, actual)
+	} else {
+		respBody, err := extractBody(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		actual.Body = string(respBody)
+		if body, coding := models.DecodeContent(resp.Header, respBody); coding != "" {
+			actual.Body = string(body)
+			actual.Headers = withoutContentEncoding(resp.Header)
+		}
+		differences = models.DiffResponses(*expected, actual)
+	}
+
+	if len(differences) > 0 {
+		hf.ResponseDiffs.Add(models.ResponseDiff{
+			Request:     requestDetails,
+			Time:        time.Now(),
+			Differences: differences,
+		})
+
+		log.WithFields(log.Fields{
+			"mode":        DiffMode,
+			"path":        requestDetails.Path,
+			"method":      requestDetails.Method,
+			"destination": requestDetails.Destination,
+			"differences": len(differences),
+		}).Warn("Response differs from the simulation")
+	}
+
+	return resp, nil
+}
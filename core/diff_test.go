@@ -0,0 +1,159 @@
+package hoverfly
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func TestHoverfly_DiffModeRecordsHowResponsesDifferFromTheSimulation(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	name := "hoverfly"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "` + name + `"}`))
+	}))
+	defer upstream.Close()
+
+	req, _ := http.NewRequest("GET", upstream.URL+"/user", nil)
+
+	unit.Cfg.SetMode(CaptureMode)
+	unit.processRequest(req)
+
+	unit.Cfg.SetMode(DiffMode)
+	unit.processRequest(req)
+	Expect(unit.GetDiff().Diffs).To(BeEmpty())
+
+	name = "drifted"
+	resp := unit.processRequest(req)
+	body, _ := ioutil.ReadAll(resp.Body)
+	Expect(string(body)).To(Equal(`{"name": "drifted"}`))
+
+	diffs := unit.GetDiff().Diffs
+	Expect(diffs).To(HaveLen(1))
+	Expect(*diffs[0].Request.Path).To(Equal("/user"))
+	Expect(diffs[0].Differences).To(HaveLen(1))
+	Expect(diffs[0].Differences[0].Field).To(Equal("body.name"))
+	Expect(diffs[0].Differences[0].Expected).To(Equal(`"hoverfly"`))
+	Expect(diffs[0].Differences[0].Actual).To(Equal(`"drifted"`))
+
+	unit.ClearDiff()
+	Expect(unit.GetDiff().Diffs).To(BeEmpty())
+}
+
+func TestHoverfly_DiffModePassesResponsesThroughWhenThereIsNothingToCompareWith(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	unit.Cfg.SetMode(CaptureMode)
+	req, _ := http.NewRequest("GET", "http://somehost.com/user", nil)
+	unit.processRequest(req)
+
+	unit.Cfg.SetMode(DiffMode)
+	req, _ = http.NewRequest("GET", "http://somehost.com/orders", nil)
+	resp := unit.processRequest(req)
+
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	Expect(unit.GetDiff().Diffs).To(BeEmpty())
+}
+
+func TestHoverfly_DiffModeComparesWithTheRenderedTemplateAndLeavesScenariosAlone(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("order " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	req, _ := http.NewRequest("GET", upstream.URL+"/orders/1", nil)
+	requestDetails, _ := models.NewRequestDetailsFromHttpRequest(req)
+
+	unit.RequestMatcher.Scenarios.Register("order")
+	unit.RequestMatcher.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request: requestDetails,
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "order {{ .Request.Path }}",
+			Headers: map[string][]string{
+				"Content-Type": []string{"text/plain"},
+				"Hoverfly":     []string{"Was-Here"},
+			},
+			Templated: true,
+		},
+		Scenario:              "order",
+		RequiredScenarioState: matching.ScenarioStarted,
+		NewScenarioState:      "shipped",
+	})
+
+	unit.Cfg.SetMode(DiffMode)
+	unit.processRequest(req)
+	unit.processRequest(req)
+
+	Expect(unit.GetDiff().Diffs).To(BeEmpty())
+	Expect(unit.RequestMatcher.Scenarios.GetState("order")).To(Equal(matching.ScenarioStarted))
+}
+
+func TestHoverfly_DiffModeDoesNotWaitForEventStreamsToEnd(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	done := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-done
+	}))
+	defer upstream.Close()
+	defer close(done)
+
+	req, _ := http.NewRequest("GET", upstream.URL+"/events", nil)
+	requestDetails, _ := models.NewRequestDetailsFromHttpRequest(req)
+
+	unit.RequestMatcher.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request: requestDetails,
+		Response: models.ResponseDetails{
+			Status: 200,
+			Headers: map[string][]string{
+				"Content-Type": []string{"application/json"},
+				"Hoverfly":     []string{"Was-Here"},
+			},
+		},
+	})
+
+	unit.Cfg.SetMode(DiffMode)
+
+	responses := make(chan *http.Response, 1)
+	go func() { responses <- unit.processRequest(req) }()
+
+	var resp *http.Response
+	Eventually(responses, time.Second).Should(Receive(&resp))
+	defer resp.Body.Close()
+
+	diffs := unit.GetDiff().Diffs
+	Expect(diffs).To(HaveLen(1))
+	Expect(diffs[0].Differences).To(HaveLen(1))
+	Expect(diffs[0].Differences[0].Field).To(Equal("headers.Content-Type"))
+}
@@ -0,0 +1,75 @@
+package hoverfly
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// withoutContentEncoding copies the headers of a response whose body has been
+// decoded, dropping those which describe the encoded body
+func withoutContentEncoding(header http.Header) http.Header {
+	decoded := http.Header{}
+	for name, values := range header {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Encoding", "Content-Length":
+			continue
+		}
+		decoded[name] = values
+	}
+	return decoded
+}
+
+// acceptsEncoding reports whether the Accept-Encoding of a request allows the coding
+func acceptsEncoding(req *http.Request, coding string) bool {
+	for _, accepted := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(accepted, ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if name != coding && name != "*" && !(coding == models.EncodingGzip && name == "x-gzip") {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// encodeResponse compresses a response which was decoded on capture with its
+// original coding, when the client accepts it. Anyone else gets it uncompressed.
+func encodeResponse(req *http.Request, response *http.Response, coding string) {
+	if !acceptsEncoding(req, coding) {
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err == nil {
+		var encoded []byte
+		if encoded, err = models.EncodeBody(body, coding); err == nil {
+			body = encoded
+			response.Header.Set("Content-Encoding", coding)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err.Error(),
+			"encoding": coding,
+		}).Error("Failed to encode response body")
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+}
@@ -0,0 +1,140 @@
+package hoverfly
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	Expect(acceptsEncoding(req, "gzip")).To(BeFalse())
+
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.5")
+	Expect(acceptsEncoding(req, "gzip")).To(BeTrue())
+	Expect(acceptsEncoding(req, "deflate")).To(BeTrue())
+
+	req.Header.Set("Accept-Encoding", "gzip;q=0, *")
+	Expect(acceptsEncoding(req, "gzip")).To(BeFalse())
+	Expect(acceptsEncoding(req, "deflate")).To(BeTrue())
+}
+
+// gzipRequest posts a gzipped body through the proxy, asking for gzip or identity
+func gzipRequest(proxy *httptest.Server, target string, acceptEncoding string) *http.Response {
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(proxy.URL)
+		},
+		DisableCompression: true,
+	}}
+
+	body, _ := models.EncodeBody([]byte(`{"name": "hoverfly"}`), models.EncodingGzip)
+	req, _ := http.NewRequest("POST", target, bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := client.Do(req)
+	Expect(err).To(BeNil())
+	return resp
+}
+
+func TestHoverfly_DecodesCompressedBodiesOnCaptureAndEncodesThemOnPlayback(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := models.EncodeBody([]byte("compressed"), models.EncodingGzip)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	unit.Cfg.SetMode("capture")
+	resp := gzipRequest(proxy, upstream.URL, "gzip")
+	Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+	resp.Body.Close()
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+
+	pair := simulation.RequestResponsePairs[0]
+	Expect(*pair.Request.Body).To(Equal(`{"name": "hoverfly"}`))
+	Expect(pair.Response.Body).To(Equal("compressed"))
+	Expect(pair.Response.ContentEncoding).To(Equal("gzip"))
+	Expect(pair.Response.Headers).ToNot(HaveKey("Content-Encoding"))
+
+	unit.Cfg.SetMode("simulate")
+	upstream.Close()
+
+	resp = gzipRequest(proxy, upstream.URL, "gzip")
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	decoded, err := models.DecodeBody(body, models.EncodingGzip)
+	Expect(err).To(BeNil())
+	Expect(string(decoded)).To(Equal("compressed"))
+
+	resp = gzipRequest(proxy, upstream.URL, "identity")
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Expect(resp.Header.Get("Content-Encoding")).To(Equal(""))
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	Expect(string(body)).To(Equal("compressed"))
+}
+
+func TestHoverfly_StoresChunkedCompressedResponsesReadable(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := models.EncodeBody([]byte("compressed in chunks"), models.EncodingGzip)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "text/plain")
+
+		// flushing part way through sends the body chunked
+		w.Write(body[:len(body)/2])
+		w.(http.Flusher).Flush()
+		w.Write(body[len(body)/2:])
+	}))
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	unit.Cfg.SetMode("capture")
+	resp := gzipRequest(proxy, upstream.URL, "gzip")
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	decoded, err := models.DecodeBody(body, models.EncodingGzip)
+	Expect(err).To(BeNil())
+	Expect(string(decoded)).To(Equal("compressed in chunks"))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+
+	pair := simulation.RequestResponsePairs[0]
+	Expect(pair.Response.Body).To(Equal("compressed in chunks"))
+	Expect(pair.Response.EncodedBody).To(BeFalse())
+	Expect(pair.Response.ContentEncoding).To(Equal("gzip"))
+	Expect(pair.Response.Headers).ToNot(HaveKey("Content-Encoding"))
+}
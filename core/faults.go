@@ -0,0 +1,128 @@
+package hoverfly
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/rusenask/goproxy"
+)
+
+// connectionFault is the body of a response whose fault is injected on the
+// client connection. If the connection cannot be hijacked, reading the body
+// fails so that the connection is at least cut short.
+type connectionFault struct {
+	faultType string
+}
+
+func (this *connectionFault) Read(p []byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func (this *connectionFault) Close() error { return nil }
+
+// truncatedBody fails once the part of the body it holds has been read
+type truncatedBody struct {
+	io.Reader
+}
+
+func (this *truncatedBody) Read(p []byte) (int, error) {
+	n, err := this.Reader.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (this *truncatedBody) Close() error { return nil }
+
+// faultFor returns the fault for a simulated response. A fault on the matched pair
+// takes the place of any global fault for the request, so at most one is injected.
+func (hf *Hoverfly) faultFor(requestDetails models.RequestDetails, simulated *models.ResponseDetails) *models.Fault {
+	if simulated != nil && simulated.Fault != nil {
+		return simulated.Fault
+	}
+	return hf.ResponseFaults.GetFault(requestDetails)
+}
+
+// injectFault replaces or alters the response to simulate the fault
+func injectFault(req *http.Request, response *http.Response, fault *models.Fault) *http.Response {
+	log.WithFields(log.Fields{
+		"fault":       fault.Type,
+		"path":        req.URL.Path,
+		"method":      req.Method,
+		"destination": req.Host,
+	}).Info("Injecting fault")
+
+	switch fault.Type {
+	case models.FaultStatus:
+		status := fault.GetStatus()
+		return goproxy.NewResponse(req, goproxy.ContentTypeText, status, http.StatusText(status))
+
+	case models.FaultTruncate:
+		body, err := extractBody(response)
+		if err != nil {
+			return hoverflyError(req, err, "Could not truncate response", http.StatusInternalServerError)
+		}
+
+		// an empty body is declared one byte long so that it is still cut short
+		length := len(body)
+		if length == 0 {
+			length = 1
+		}
+
+		response.Body = &truncatedBody{Reader: bytes.NewReader(body[:len(body)/2])}
+		response.ContentLength = int64(length)
+		response.Header.Set("Content-Length", strconv.Itoa(length))
+		return response
+
+	case models.FaultHang:
+		// requests read from a MITM connection have no context to end, so they hang forever
+		<-req.Context().Done()
+		response.Body = &connectionFault{faultType: models.FaultClose}
+		return response
+	}
+
+	response.Body = &connectionFault{faultType: fault.Type}
+	return response
+}
+
+// injectConnectionFault takes over the client connection when the response
+// carries a connection fault, returning true when nothing more should be written
+func injectConnectionFault(w http.ResponseWriter, response *http.Response) bool {
+	fault, ok := response.Body.(*connectionFault)
+	if !ok {
+		return false
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		log.Warn("Could not inject fault, the client connection cannot be hijacked")
+		return false
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Warn("Could not inject fault, failed to hijack the client connection")
+		return false
+	}
+
+	switch fault.faultType {
+	case models.FaultReset:
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0)
+		}
+	case models.FaultGarbage:
+		garbage := make([]byte, 256)
+		rand.Read(garbage)
+		conn.Write(garbage)
+	}
+
+	conn.Close()
+	return true
+}
@@ -0,0 +1,198 @@
+package hoverfly
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func faultySimulation(fault *v1.FaultView) v2.SimulationView {
+	return v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request: v2.RequestDetailsView{
+						RequestType: util.StringToPointer("template"),
+						Path:        util.StringToPointer("/faulty"),
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   "a body that will not arrive in one piece",
+						Fault:  fault,
+					},
+				},
+			},
+		},
+	}
+}
+
+func faultyWebserver(fault *v1.FaultView) (*httptest.Server, *Hoverfly) {
+	server, unit := testTools(201, `{'message': 'here'}`)
+	server.Close()
+
+	Expect(unit.PutSimulation(faultySimulation(fault))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+	unit.Cfg.Webserver = true
+
+	return httptest.NewServer(withClientWriter(NewWebserverProxy(unit))), unit
+}
+
+func TestInjectFault_ReturnsTheStatusOfAStatusFault(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutSimulation(faultySimulation(&v1.FaultView{Type: "status", Status: 500}))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	r, _ := http.NewRequest("GET", "http://test.com/faulty", nil)
+	response := unit.processRequest(r)
+
+	Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+}
+
+func TestInjectFault_InjectsGlobalFaultsMatchingTheRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	simulation := faultySimulation(nil)
+	simulation.GlobalActions.Faults = []v2.ResponseFaultView{
+		{UrlPattern: "test.com/faulty", HttpMethod: "POST", Type: "status"},
+	}
+	Expect(unit.PutSimulation(simulation)).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	get, _ := http.NewRequest("GET", "http://test.com/faulty", nil)
+	Expect(unit.processRequest(get).StatusCode).To(Equal(http.StatusOK))
+
+	post, _ := http.NewRequest("POST", "http://test.com/faulty", nil)
+	Expect(unit.processRequest(post).StatusCode).To(Equal(http.StatusServiceUnavailable))
+}
+
+func TestInjectFault_TruncatesTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	webserver, _ := faultyWebserver(&v1.FaultView{Type: "truncate"})
+	defer webserver.Close()
+
+	response, err := http.Get(webserver.URL + "/faulty")
+	Expect(err).To(BeNil())
+	Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).ToNot(BeNil())
+	Expect(string(body)).To(Equal("a body that will not"))
+}
+
+func TestInjectFault_ClosesTheConnection(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, faultType := range []string{"close", "reset", "garbage"} {
+		webserver, _ := faultyWebserver(&v1.FaultView{Type: faultType})
+
+		_, err := http.Get(webserver.URL + "/faulty")
+		Expect(err).ToNot(BeNil(), faultType)
+
+		webserver.Close()
+	}
+}
+
+func TestInjectFault_HangsUntilTheClientGivesUp(t *testing.T) {
+	RegisterTestingT(t)
+
+	webserver, _ := faultyWebserver(&v1.FaultView{Type: "hang"})
+	defer webserver.Close()
+
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	_, err := client.Get(webserver.URL + "/faulty")
+	Expect(err).ToNot(BeNil())
+}
+
+func TestInjectFault_ClosesTheConnectionThroughTheProxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutSimulation(faultySimulation(&v1.FaultView{Type: "reset"}))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(proxy.URL)
+		},
+	}}
+
+	_, err := client.Get("http://test.com/faulty")
+	Expect(err).ToNot(BeNil())
+}
+
+func TestInjectFault_OnlyInjectsTheFaultForItsPercentage(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutSimulation(faultySimulation(&v1.FaultView{Type: "status", Percentage: util.IntToPointer(50)}))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	statuses := map[int]int{}
+	for i := 0; i < 200; i++ {
+		r, _ := http.NewRequest("GET", "http://test.com/faulty", nil)
+		statuses[unit.processRequest(r).StatusCode]++
+	}
+
+	Expect(statuses[http.StatusOK]).To(BeNumerically(">", 0))
+	Expect(statuses[http.StatusServiceUnavailable]).To(BeNumerically(">", 0))
+}
+
+func TestInjectFault_AFaultOnThePairTakesThePlaceOfGlobalFaults(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	simulation := faultySimulation(&v1.FaultView{Type: "status", Percentage: util.IntToPointer(0)})
+	simulation.GlobalActions.Faults = []v2.ResponseFaultView{
+		{UrlPattern: "test.com/faulty", Type: "status"},
+	}
+	Expect(unit.PutSimulation(simulation)).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	r, _ := http.NewRequest("GET", "http://test.com/faulty", nil)
+	Expect(unit.processRequest(r).StatusCode).To(Equal(http.StatusOK))
+}
+
+func TestInjectFault_OnlyInjectsFaultsIntoSimulatedResponsesInSpyMode(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	simulation := faultySimulation(nil)
+	simulation.GlobalActions.Faults = []v2.ResponseFaultView{
+		{UrlPattern: "test.com/.*", Type: "status"},
+	}
+	Expect(unit.PutSimulation(simulation)).To(BeNil())
+	unit.Cfg.SetMode("spy")
+
+	simulated, _ := http.NewRequest("GET", "http://test.com/faulty", nil)
+	Expect(unit.processRequest(simulated).StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+	forwarded, _ := http.NewRequest("GET", "http://test.com/other", nil)
+	Expect(unit.processRequest(forwarded).StatusCode).To(Equal(http.StatusCreated))
+}
@@ -78,6 +78,8 @@ func (this *StateHandler) Post(w http.ResponseWriter, r *http.Request, next http
 		"capture":    true,
 		"modify":     true,
 		"synthesize": true,
+		"spy":        true,
+		"diff":       true,
 	}
 
 	if sr.Mode != "" {
@@ -85,7 +87,7 @@ func (this *StateHandler) Post(w http.ResponseWriter, r *http.Request, next http
 			log.WithFields(log.Fields{
 				"suppliedMode": sr.Mode,
 			}).Error("Wrong mode found, can't change state")
-			http.Error(w, "Bad mode supplied, available modes: simulate, capture, modify, synthesize.", 400)
+			http.Error(w, "Bad mode supplied, available modes: simulate, capture, modify, synthesize, spy, diff.", 400)
 			return
 		}
 		log.WithFields(log.Fields{
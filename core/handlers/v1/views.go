@@ -51,8 +51,12 @@ func (m *MessageResponse) Encode() ([]byte, error) {
 }
 
 type RequestTemplateResponsePairView struct {
-	RequestTemplate RequestTemplateView `json:"requestTemplate"`
-	Response        ResponseDetailsView `json:"response"`
+	RequestTemplate       RequestTemplateView `json:"requestTemplate"`
+	Response              ResponseDetailsView `json:"response"`
+	Priority              int                 `json:"priority,omitempty"`
+	Scenario              string              `json:"scenario,omitempty"`
+	RequiredScenarioState string              `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string              `json:"newScenarioState,omitempty"`
 }
 
 type RequestTemplateResponsePairPayload struct {
@@ -67,14 +71,59 @@ type RequestTemplateView struct {
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
 	Headers     map[string][]string `json:"headers"`
+	QueryParams map[string][]string `json:"queryParams,omitempty"`
 }
 
 type ResponseDelayView struct {
-	UrlPattern string `json:"urlPattern"`
-	HttpMethod string `json:"httpMethod"`
-	Delay      int    `json:"delay"`
+	UrlPattern   string `json:"urlPattern"`
+	HttpMethod   string `json:"httpMethod"`
+	Delay        int    `json:"delay"`
+	Distribution string `json:"distribution,omitempty"`
+	Min          int    `json:"min,omitempty"`
+	Max          int    `json:"max,omitempty"`
+	Mean         int    `json:"mean,omitempty"`
+	StdDev       int    `json:"stdDev,omitempty"`
+	Median       int    `json:"median,omitempty"`
+	P99          int    `json:"p99,omitempty"`
+	// Request - matches requests as a request template does
+	Request *RequestDetailsView `json:"request,omitempty"`
 }
 
+type DelayView struct {
+	Delay        int    `json:"delay"`
+	Distribution string `json:"distribution,omitempty"`
+	Min          int    `json:"min,omitempty"`
+	Max          int    `json:"max,omitempty"`
+	Mean         int    `json:"mean,omitempty"`
+	StdDev       int    `json:"stdDev,omitempty"`
+	Median       int    `json:"median,omitempty"`
+	P99          int    `json:"p99,omitempty"`
+}
+
+// Gets Delay - required for interfaces.Delay
+func (this DelayView) GetDelay() int { return this.Delay }
+
+// Gets Distribution - required for interfaces.Delay
+func (this DelayView) GetDistribution() string { return this.Distribution }
+
+// Gets Min - required for interfaces.Delay
+func (this DelayView) GetMin() int { return this.Min }
+
+// Gets Max - required for interfaces.Delay
+func (this DelayView) GetMax() int { return this.Max }
+
+// Gets Mean - required for interfaces.Delay
+func (this DelayView) GetMean() int { return this.Mean }
+
+// Gets StdDev - required for interfaces.Delay
+func (this DelayView) GetStdDev() int { return this.StdDev }
+
+// Gets Median - required for interfaces.Delay
+func (this DelayView) GetMedian() int { return this.Median }
+
+// Gets P99 - required for interfaces.Delay
+func (this DelayView) GetP99() int { return this.P99 }
+
 type ResponseDelayPayloadView struct {
 	Data []ResponseDelayView `json:"data"`
 }
@@ -85,8 +134,12 @@ type RequestResponsePairPayload struct {
 
 // PayloadView is used when marshalling and unmarshalling payloads.
 type RequestResponsePairView struct {
-	Response ResponseDetailsView `json:"response"`
-	Request  RequestDetailsView  `json:"request"`
+	Response              ResponseDetailsView `json:"response"`
+	Request               RequestDetailsView  `json:"request"`
+	Priority              int                 `json:"priority,omitempty"`
+	Scenario              string              `json:"scenario,omitempty"`
+	RequiredScenarioState string              `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string              `json:"newScenarioState,omitempty"`
 }
 
 //Gets Response - required for interfaces.RequestResponsePairView
@@ -95,6 +148,20 @@ func (this RequestResponsePairView) GetResponse() interfaces.Response { return t
 //Gets Request - required for interfaces.RequestResponsePairView
 func (this RequestResponsePairView) GetRequest() interfaces.Request { return this.Request }
 
+//Gets Priority - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetPriority() int { return this.Priority }
+
+//Gets Scenario - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetScenario() string { return this.Scenario }
+
+//Gets RequiredScenarioState - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetRequiredScenarioState() string {
+	return this.RequiredScenarioState
+}
+
+//Gets NewScenarioState - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetNewScenarioState() string { return this.NewScenarioState }
+
 // RequestDetailsView is used when marshalling and unmarshalling RequestDetails
 type RequestDetailsView struct {
 	RequestType *string             `json:"requestType"`
@@ -104,7 +171,10 @@ type RequestDetailsView struct {
 	Scheme      *string             `json:"scheme"`
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
+	EncodedBody bool                `json:"encodedBody,omitempty"`
 	Headers     map[string][]string `json:"headers"`
+	HeaderOrder []string            `json:"headerOrder,omitempty"`
+	QueryParams map[string][]string `json:"queryParams,omitempty"`
 }
 
 //Gets RequestType - required for interfaces.Request
@@ -128,17 +198,34 @@ func (this RequestDetailsView) GetQuery() *string { return this.Query }
 //Gets Body - required for interfaces.Request
 func (this RequestDetailsView) GetBody() *string { return this.Body }
 
+//Gets EncodedBody - required for interfaces.Request
+func (this RequestDetailsView) GetEncodedBody() bool { return this.EncodedBody }
+
 //Gets Headers - required for interfaces.Request
 func (this RequestDetailsView) GetHeaders() map[string][]string { return this.Headers }
 
+// Gets HeaderOrder - required for interfaces.Request
+func (this RequestDetailsView) GetHeaderOrder() []string { return this.HeaderOrder }
+
+//Gets QueryParams - required for interfaces.Request
+func (this RequestDetailsView) GetQueryParams() map[string][]string { return this.QueryParams }
+
 // ResponseDetailsView is used when marshalling and
 // unmarshalling requests. This struct's Body may be Base64
 // encoded based on the EncodedBody field.
 type ResponseDetailsView struct {
-	Status      int                 `json:"status"`
-	Body        string              `json:"body"`
-	EncodedBody bool                `json:"encodedBody"`
-	Headers     map[string][]string `json:"headers"`
+	Status            int                    `json:"status"`
+	Body              string                 `json:"body"`
+	EncodedBody       bool                   `json:"encodedBody"`
+	Headers           map[string][]string    `json:"headers"`
+	HeaderOrder       []string               `json:"headerOrder,omitempty"`
+	Trailers          map[string][]string    `json:"trailers,omitempty"`
+	ContentEncoding   string                 `json:"contentEncoding,omitempty"`
+	Templated         bool                   `json:"templated,omitempty"`
+	Fault             *FaultView             `json:"fault,omitempty"`
+	Delay             *DelayView             `json:"delay,omitempty"`
+	Events            []ResponseEventView    `json:"events,omitempty"`
+	WebSocketMessages []WebSocketMessageView `json:"webSocketMessages,omitempty"`
 }
 
 //Gets Status - required for interfaces.Response
@@ -152,3 +239,100 @@ func (this ResponseDetailsView) GetEncodedBody() bool { return this.EncodedBody
 
 // Gets Headers - required for interfaces.Response
 func (this ResponseDetailsView) GetHeaders() map[string][]string { return this.Headers }
+
+// Gets HeaderOrder - required for interfaces.Response
+func (this ResponseDetailsView) GetHeaderOrder() []string { return this.HeaderOrder }
+
+// Gets Trailers - required for interfaces.Response
+func (this ResponseDetailsView) GetTrailers() map[string][]string { return this.Trailers }
+
+// Gets ContentEncoding - required for interfaces.Response
+func (this ResponseDetailsView) GetContentEncoding() string { return this.ContentEncoding }
+
+// Gets Templated - required for interfaces.Response
+func (this ResponseDetailsView) GetTemplated() bool { return this.Templated }
+
+// Gets Fault - required for interfaces.Response
+func (this ResponseDetailsView) GetFault() interfaces.Fault {
+	if this.Fault == nil {
+		return nil
+	}
+	return this.Fault
+}
+
+// Gets Delay - required for interfaces.Response
+func (this ResponseDetailsView) GetDelay() interfaces.Delay {
+	if this.Delay == nil {
+		return nil
+	}
+	return this.Delay
+}
+
+// Gets Events - required for interfaces.Response
+func (this ResponseDetailsView) GetEvents() []interfaces.ResponseEvent {
+	events := make([]interfaces.ResponseEvent, len(this.Events))
+	for i, event := range this.Events {
+		events[i] = event
+	}
+	return events
+}
+
+// Gets WebSocketMessages - required for interfaces.Response
+func (this ResponseDetailsView) GetWebSocketMessages() []interfaces.WebSocketMessage {
+	messages := make([]interfaces.WebSocketMessage, len(this.WebSocketMessages))
+	for i, message := range this.WebSocketMessages {
+		messages[i] = message
+	}
+	return messages
+}
+
+type ResponseEventView struct {
+	Time int    `json:"time"`
+	Data string `json:"data"`
+}
+
+// Gets Time - required for interfaces.ResponseEvent
+func (this ResponseEventView) GetTime() int { return this.Time }
+
+// Gets Data - required for interfaces.ResponseEvent
+func (this ResponseEventView) GetData() string { return this.Data }
+
+// WebSocketMessageView is a message sent over a WebSocket connection. The Data
+// of a binary message is Base64 encoded.
+type WebSocketMessageView struct {
+	From    string `json:"from"`
+	Type    string `json:"type"`
+	Data    string `json:"data"`
+	Time    int    `json:"time"`
+	Matcher string `json:"matcher,omitempty"`
+}
+
+// Gets From - required for interfaces.WebSocketMessage
+func (this WebSocketMessageView) GetFrom() string { return this.From }
+
+// Gets Type - required for interfaces.WebSocketMessage
+func (this WebSocketMessageView) GetType() string { return this.Type }
+
+// Gets Data - required for interfaces.WebSocketMessage
+func (this WebSocketMessageView) GetData() string { return this.Data }
+
+// Gets Time - required for interfaces.WebSocketMessage
+func (this WebSocketMessageView) GetTime() int { return this.Time }
+
+// Gets Matcher - required for interfaces.WebSocketMessage
+func (this WebSocketMessageView) GetMatcher() string { return this.Matcher }
+
+type FaultView struct {
+	Type       string `json:"type"`
+	Percentage *int   `json:"percentage,omitempty"`
+	Status     int    `json:"status,omitempty"`
+}
+
+// Gets Type - required for interfaces.Fault
+func (this FaultView) GetType() string { return this.Type }
+
+// Gets Percentage - required for interfaces.Fault
+func (this FaultView) GetPercentage() *int { return this.Percentage }
+
+// Gets Status - required for interfaces.Fault
+func (this FaultView) GetStatus() int { return this.Status }
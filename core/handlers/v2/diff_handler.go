@@ -0,0 +1,46 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyDiff interface {
+	GetDiff() DiffView
+	ClearDiff()
+}
+
+type DiffHandler struct {
+	Hoverfly HoverflyDiff
+}
+
+func (this *DiffHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/diff", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Delete("/api/v2/diff", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Delete),
+	))
+}
+
+func (this *DiffHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	diffView := this.Hoverfly.GetDiff()
+
+	bytes, _ := json.Marshal(diffView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+// Delete clears every diff found so far
+func (this *DiffHandler) Delete(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.Hoverfly.ClearDiff()
+
+	this.Get(w, req, next)
+}
@@ -0,0 +1,81 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyDiffStub struct {
+	Diffs []ResponseDiffView
+}
+
+func (this HoverflyDiffStub) GetDiff() DiffView {
+	return DiffView{Diffs: this.Diffs}
+}
+
+func (this *HoverflyDiffStub) ClearDiff() {
+	this.Diffs = []ResponseDiffView{}
+}
+
+var pathDiff = ResponseDiffView{
+	Time: "2017-03-01T12:00:00Z",
+	Differences: []DifferenceView{
+		{Field: "status", Expected: "200", Actual: "500"},
+	},
+}
+
+func TestDiffHandlerGetReturnsTheDiffs(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyDiffStub{Diffs: []ResponseDiffView{pathDiff}}
+	unit := DiffHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/diff", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	diffView, err := unmarshalDiffView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(diffView.Diffs).To(HaveLen(1))
+	Expect(diffView.Diffs[0].Differences).To(Equal(pathDiff.Differences))
+}
+
+func TestDiffHandlerDeleteClearsTheDiffs(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyDiffStub{Diffs: []ResponseDiffView{pathDiff}}
+	unit := DiffHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("DELETE", "/api/v2/diff", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Delete, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	diffView, err := unmarshalDiffView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(diffView.Diffs).To(BeEmpty())
+}
+
+func unmarshalDiffView(buffer *bytes.Buffer) (DiffView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return DiffView{}, err
+	}
+
+	var diffView DiffView
+
+	err = json.Unmarshal(body, &diffView)
+	if err != nil {
+		return DiffView{}, err
+	}
+
+	return diffView, nil
+}
@@ -13,6 +13,7 @@ type Hoverfly interface {
 	GetDestination() string
 	GetMiddleware() string
 	GetMode() string
+	GetRoutes() RoutesView
 	GetStats() metrics.Stats
 }
 
@@ -33,6 +34,7 @@ func (this *HoverflyHandler) Get(w http.ResponseWriter, req *http.Request, next
 	hoverflyView.Destination = this.Hoverfly.GetDestination()
 	hoverflyView.Mode = this.Hoverfly.GetMode()
 	hoverflyView.Middleware = this.Hoverfly.GetMiddleware()
+	hoverflyView.Routes = this.Hoverfly.GetRoutes().Routes
 	hoverflyView.Usage = this.Hoverfly.GetStats()
 
 	bytes, _ := json.Marshal(hoverflyView)
@@ -24,6 +24,10 @@ func (this HoverflyStub) GetMiddleware() string {
 	return "test-middleware"
 }
 
+func (this HoverflyStub) GetRoutes() RoutesView {
+	return RoutesView{Routes: []RouteView{{Destination: "payments.internal", Mode: "capture"}}}
+}
+
 func (this HoverflyStub) GetStats() metrics.Stats {
 	metrics := metrics.Stats{
 		Counters: make(map[string]int64),
@@ -53,6 +57,7 @@ func TestHoverflyHandlerGetReturnsTheCorrectMode(t *testing.T) {
 	Expect(hoverflyView.Destination).To(Equal("test-destination.com"))
 	Expect(hoverflyView.Mode).To(Equal("test-mode"))
 	Expect(hoverflyView.Middleware).To(Equal("test-middleware"))
+	Expect(hoverflyView.Routes).To(Equal([]RouteView{{Destination: "payments.internal", Mode: "capture"}}))
 }
 
 func unmarshalHoverflyView(buffer *bytes.Buffer) (HoverflyView, error) {
@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+	"io/ioutil"
+	"net/http"
+)
+
+type HoverflyHashing interface {
+	GetHashConfiguration() HashConfigurationView
+	SetHashConfiguration(HashConfigurationView) error
+}
+
+type HoverflyHashingHandler struct {
+	Hoverfly HoverflyHashing
+}
+
+func (this *HoverflyHashingHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/hoverfly/hashing", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Put("/api/v2/hoverfly/hashing", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+}
+
+func (this *HoverflyHashingHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	hashingView := this.Hoverfly.GetHashConfiguration()
+
+	bytes, _ := json.Marshal(hashingView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HoverflyHashingHandler) Put(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer r.Body.Close()
+
+	var hashingView HashConfigurationView
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	err := json.Unmarshal(body, &hashingView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Malformed JSON", 400)
+		return
+	}
+
+	err = this.Hoverfly.SetHashConfiguration(hashingView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), 422)
+		return
+	}
+
+	this.Get(w, r, next)
+}
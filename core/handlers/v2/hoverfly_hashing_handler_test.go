@@ -0,0 +1,123 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type HoverflyHashingStub struct {
+	HashConfiguration HashConfigurationView
+}
+
+func (this HoverflyHashingStub) GetHashConfiguration() HashConfigurationView {
+	return this.HashConfiguration
+}
+
+func (this *HoverflyHashingStub) SetHashConfiguration(hashConfiguration HashConfigurationView) error {
+	if len(hashConfiguration.ExcludeBodyPaths) > 0 && hashConfiguration.ExcludeBodyPaths[0] == "error" {
+		return fmt.Errorf("error")
+	}
+
+	this.HashConfiguration = hashConfiguration
+	return nil
+}
+
+func TestHoverflyHashingHandlerGetReturnsTheHashConfiguration(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHashingStub{
+		HashConfiguration: HashConfigurationView{ExcludeQueryParams: []string{"_"}},
+	}
+	unit := HoverflyHashingHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	hashingView, err := unmarshalHashConfigurationView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(hashingView.ExcludeQueryParams).To(ConsistOf("_"))
+}
+
+func TestHoverflyHashingHandlerPutSetsTheHashConfiguration(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHashingStub{}
+	unit := HoverflyHashingHandler{Hoverfly: stubHoverfly}
+
+	hashingView := &HashConfigurationView{
+		IncludeHeaders:   []string{"X-Tenant"},
+		ExcludeBodyPaths: []string{"$.timestamp"},
+	}
+
+	bodyBytes, err := json.Marshal(hashingView)
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.HashConfiguration.IncludeHeaders).To(ConsistOf("X-Tenant"))
+
+	hashingViewResponse, err := unmarshalHashConfigurationView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(hashingViewResponse.ExcludeBodyPaths).To(ConsistOf("$.timestamp"))
+}
+
+func TestHoverflyHashingHandlerPutWill422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHashingStub{}
+	unit := HoverflyHashingHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, err := json.Marshal(&HashConfigurationView{ExcludeBodyPaths: []string{"error"}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorViewResponse.Error).To(Equal("error"))
+}
+
+func TestHoverflyHashingHandlerPutWill400ErrorIfJsonIsBad(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHashingStub{}
+	unit := HoverflyHashingHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer([]byte(":not json"))))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+}
+
+func unmarshalHashConfigurationView(buffer *bytes.Buffer) (HashConfigurationView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return HashConfigurationView{}, err
+	}
+
+	var hashingView HashConfigurationView
+
+	err = json.Unmarshal(body, &hashingView)
+	if err != nil {
+		return HashConfigurationView{}, err
+	}
+
+	return hashingView, nil
+}
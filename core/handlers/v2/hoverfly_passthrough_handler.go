@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+	"io/ioutil"
+	"net/http"
+)
+
+type HoverflyPassthrough interface {
+	GetPassthroughRules() PassthroughView
+	SetPassthroughRules(PassthroughView) error
+}
+
+type HoverflyPassthroughHandler struct {
+	Hoverfly HoverflyPassthrough
+}
+
+func (this *HoverflyPassthroughHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/hoverfly/passthrough", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Put("/api/v2/hoverfly/passthrough", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+}
+
+func (this *HoverflyPassthroughHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	passthroughView := this.Hoverfly.GetPassthroughRules()
+
+	bytes, _ := json.Marshal(passthroughView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HoverflyPassthroughHandler) Put(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer r.Body.Close()
+
+	var passthroughView PassthroughView
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	err := json.Unmarshal(body, &passthroughView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Malformed JSON", 400)
+		return
+	}
+
+	err = this.Hoverfly.SetPassthroughRules(passthroughView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), 422)
+		return
+	}
+
+	this.Get(w, r, next)
+}
@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type HoverflyPassthroughStub struct {
+	Passthrough PassthroughView
+}
+
+func (this HoverflyPassthroughStub) GetPassthroughRules() PassthroughView {
+	return this.Passthrough
+}
+
+func (this *HoverflyPassthroughStub) SetPassthroughRules(passthrough PassthroughView) error {
+	for _, rule := range passthrough.Rules {
+		if rule.Action == "error" {
+			return fmt.Errorf("error")
+		}
+	}
+
+	this.Passthrough = passthrough
+	return nil
+}
+
+func TestHoverflyPassthroughHandlerGetReturnsTheRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	rule := PassthroughRuleView{Action: "exclude", PathPrefix: "/health"}
+	stubHoverfly := &HoverflyPassthroughStub{Passthrough: PassthroughView{Rules: []PassthroughRuleView{rule}}}
+	unit := HoverflyPassthroughHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	passthroughView, err := unmarshalPassthroughView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(passthroughView.Rules).To(ConsistOf(rule))
+}
+
+func TestHoverflyPassthroughHandlerPutSetsTheRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyPassthroughStub{}
+	unit := HoverflyPassthroughHandler{Hoverfly: stubHoverfly}
+
+	rule := PassthroughRuleView{Action: "exclude", Host: "*.analytics.com", Method: "POST"}
+	bodyBytes, err := json.Marshal(&PassthroughView{Rules: []PassthroughRuleView{rule}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.Passthrough.Rules).To(ConsistOf(rule))
+
+	passthroughView, err := unmarshalPassthroughView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(passthroughView.Rules).To(ConsistOf(rule))
+}
+
+func TestHoverflyPassthroughHandlerPutWill422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyPassthroughStub{}
+	unit := HoverflyPassthroughHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, err := json.Marshal(&PassthroughView{Rules: []PassthroughRuleView{{Action: "error"}}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorViewResponse.Error).To(Equal("error"))
+}
+
+func unmarshalPassthroughView(buffer *bytes.Buffer) (PassthroughView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return PassthroughView{}, err
+	}
+
+	var passthroughView PassthroughView
+
+	err = json.Unmarshal(body, &passthroughView)
+	if err != nil {
+		return PassthroughView{}, err
+	}
+
+	return passthroughView, nil
+}
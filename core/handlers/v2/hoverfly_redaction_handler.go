@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+	"io/ioutil"
+	"net/http"
+)
+
+type HoverflyRedaction interface {
+	GetRedaction() RedactionView
+	SetRedaction(RedactionView) error
+}
+
+type HoverflyRedactionHandler struct {
+	Hoverfly HoverflyRedaction
+}
+
+func (this *HoverflyRedactionHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/hoverfly/redaction", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Put("/api/v2/hoverfly/redaction", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+}
+
+func (this *HoverflyRedactionHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	redactionView := this.Hoverfly.GetRedaction()
+
+	bytes, _ := json.Marshal(redactionView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HoverflyRedactionHandler) Put(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer r.Body.Close()
+
+	var redactionView RedactionView
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	err := json.Unmarshal(body, &redactionView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Malformed JSON", 400)
+		return
+	}
+
+	err = this.Hoverfly.SetRedaction(redactionView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), 422)
+		return
+	}
+
+	this.Get(w, r, next)
+}
@@ -0,0 +1,107 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type HoverflyRedactionStub struct {
+	Redaction RedactionView
+}
+
+func (this HoverflyRedactionStub) GetRedaction() RedactionView {
+	return this.Redaction
+}
+
+func (this *HoverflyRedactionStub) SetRedaction(redaction RedactionView) error {
+	if len(redaction.MaskBodyPaths) > 0 && redaction.MaskBodyPaths[0] == "error" {
+		return fmt.Errorf("error")
+	}
+
+	this.Redaction = redaction
+	return nil
+}
+
+func TestHoverflyRedactionHandlerGetReturnsTheRedaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRedactionStub{
+		Redaction: RedactionView{DropHeaders: []string{"Cookie"}},
+	}
+	unit := HoverflyRedactionHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	redactionView, err := unmarshalRedactionView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(redactionView.DropHeaders).To(ConsistOf("Cookie"))
+}
+
+func TestHoverflyRedactionHandlerPutSetsTheRedaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRedactionStub{}
+	unit := HoverflyRedactionHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, err := json.Marshal(&RedactionView{
+		MaskHeaders:      []string{"Authorization"},
+		BodyReplacements: []BodyReplacementView{{Pattern: "secret", Replacement: "***"}},
+	})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.Redaction.MaskHeaders).To(ConsistOf("Authorization"))
+
+	redactionView, err := unmarshalRedactionView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(redactionView.BodyReplacements).To(ConsistOf(BodyReplacementView{Pattern: "secret", Replacement: "***"}))
+}
+
+func TestHoverflyRedactionHandlerPutWill422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRedactionStub{}
+	unit := HoverflyRedactionHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, err := json.Marshal(&RedactionView{MaskBodyPaths: []string{"error"}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorViewResponse.Error).To(Equal("error"))
+}
+
+func unmarshalRedactionView(buffer *bytes.Buffer) (RedactionView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return RedactionView{}, err
+	}
+
+	var redactionView RedactionView
+
+	err = json.Unmarshal(body, &redactionView)
+	if err != nil {
+		return RedactionView{}, err
+	}
+
+	return redactionView, nil
+}
@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+	"io/ioutil"
+	"net/http"
+)
+
+type HoverflyRoutes interface {
+	GetRoutes() RoutesView
+	SetRoutes(RoutesView) error
+}
+
+type HoverflyRoutesHandler struct {
+	Hoverfly HoverflyRoutes
+}
+
+func (this *HoverflyRoutesHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/hoverfly/routes", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Put("/api/v2/hoverfly/routes", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+}
+
+func (this *HoverflyRoutesHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	routesView := this.Hoverfly.GetRoutes()
+
+	bytes, _ := json.Marshal(routesView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HoverflyRoutesHandler) Put(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer r.Body.Close()
+
+	var routesView RoutesView
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	err := json.Unmarshal(body, &routesView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Malformed JSON", 400)
+		return
+	}
+
+	err = this.Hoverfly.SetRoutes(routesView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), 422)
+		return
+	}
+
+	this.Get(w, r, next)
+}
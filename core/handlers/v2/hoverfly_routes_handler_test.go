@@ -0,0 +1,107 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type HoverflyRoutesStub struct {
+	Routes RoutesView
+}
+
+func (this HoverflyRoutesStub) GetRoutes() RoutesView {
+	return this.Routes
+}
+
+func (this *HoverflyRoutesStub) SetRoutes(routes RoutesView) error {
+	for _, route := range routes.Routes {
+		if route.Mode == "error" {
+			return fmt.Errorf("error")
+		}
+	}
+
+	this.Routes = routes
+	return nil
+}
+
+func TestHoverflyRoutesHandlerGetReturnsTheRoutes(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRoutesStub{
+		Routes: RoutesView{Routes: []RouteView{{Destination: "payments.internal", Mode: "capture"}}},
+	}
+	unit := HoverflyRoutesHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	routesView, err := unmarshalRoutesView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(routesView.Routes).To(ConsistOf(RouteView{Destination: "payments.internal", Mode: "capture"}))
+}
+
+func TestHoverflyRoutesHandlerPutSetsTheRoutes(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRoutesStub{}
+	unit := HoverflyRoutesHandler{Hoverfly: stubHoverfly}
+
+	routes := RouteView{Destination: "ledger.internal", Mode: "modify", Middleware: "./middleware.py"}
+	bodyBytes, err := json.Marshal(&RoutesView{Routes: []RouteView{routes}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.Routes.Routes).To(ConsistOf(routes))
+
+	routesView, err := unmarshalRoutesView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(routesView.Routes).To(ConsistOf(routes))
+}
+
+func TestHoverflyRoutesHandlerPutWill422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyRoutesStub{}
+	unit := HoverflyRoutesHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, err := json.Marshal(&RoutesView{Routes: []RouteView{{Destination: ".", Mode: "error"}}})
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorViewResponse.Error).To(Equal("error"))
+}
+
+func unmarshalRoutesView(buffer *bytes.Buffer) (RoutesView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return RoutesView{}, err
+	}
+
+	var routesView RoutesView
+
+	err = json.Unmarshal(body, &routesView)
+	if err != nil {
+		return RoutesView{}, err
+	}
+
+	return routesView, nil
+}
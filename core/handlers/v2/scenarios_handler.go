@@ -0,0 +1,63 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyScenarios interface {
+	GetScenarios() ScenariosView
+	ResetScenario(string) error
+	ResetScenarios()
+}
+
+type ScenariosHandler struct {
+	Hoverfly HoverflyScenarios
+}
+
+func (this *ScenariosHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/scenarios", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+
+	mux.Delete("/api/v2/scenarios", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Delete),
+	))
+
+	mux.Delete("/api/v2/scenarios/:name", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.DeleteScenario),
+	))
+}
+
+func (this *ScenariosHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	scenariosView := this.Hoverfly.GetScenarios()
+
+	bytes, _ := json.Marshal(scenariosView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+// Delete resets every scenario to its started state
+func (this *ScenariosHandler) Delete(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.Hoverfly.ResetScenarios()
+
+	this.Get(w, req, next)
+}
+
+// DeleteScenario resets a single scenario to its started state
+func (this *ScenariosHandler) DeleteScenario(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	err := this.Hoverfly.ResetScenario(bone.GetValue(req, "name"))
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	this.Get(w, req, next)
+}
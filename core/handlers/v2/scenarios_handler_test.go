@@ -0,0 +1,124 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type HoverflyScenariosStub struct {
+	States map[string]string
+}
+
+func (this HoverflyScenariosStub) GetScenarios() ScenariosView {
+	scenarios := []ScenarioView{}
+	for name, state := range this.States {
+		scenarios = append(scenarios, ScenarioView{Name: name, State: state})
+	}
+	return ScenariosView{Scenarios: scenarios}
+}
+
+func (this *HoverflyScenariosStub) ResetScenario(name string) error {
+	if _, ok := this.States[name]; !ok {
+		return fmt.Errorf("Scenario %s does not exist", name)
+	}
+	this.States[name] = "Started"
+	return nil
+}
+
+func (this *HoverflyScenariosStub) ResetScenarios() {
+	for name := range this.States {
+		this.States[name] = "Started"
+	}
+}
+
+func TestScenariosHandlerGetReturnsTheStateOfEachScenario(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyScenariosStub{States: map[string]string{"order": "shipped"}}
+	unit := ScenariosHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	scenariosView, err := unmarshalScenariosView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(scenariosView.Scenarios).To(ConsistOf(ScenarioView{Name: "order", State: "shipped"}))
+}
+
+func TestScenariosHandlerDeleteResetsEveryScenario(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyScenariosStub{States: map[string]string{"order": "shipped", "payment": "failed"}}
+	unit := ScenariosHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("DELETE", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Delete, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.States).To(Equal(map[string]string{"order": "Started", "payment": "Started"}))
+}
+
+func TestScenariosHandlerDeleteScenarioResetsTheNamedScenario(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyScenariosStub{States: map[string]string{"order": "shipped", "payment": "failed"}}
+	unit := ScenariosHandler{Hoverfly: stubHoverfly}
+
+	response := makeRequestOnScenarioRoute(unit, "/api/v2/scenarios/order")
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.States).To(Equal(map[string]string{"order": "Started", "payment": "failed"}))
+}
+
+func TestScenariosHandlerDeleteScenarioWill404IfTheScenarioDoesNotExist(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyScenariosStub{States: map[string]string{}}
+	unit := ScenariosHandler{Hoverfly: stubHoverfly}
+
+	response := makeRequestOnScenarioRoute(unit, "/api/v2/scenarios/order")
+	Expect(response.Code).To(Equal(http.StatusNotFound))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Scenario order does not exist"))
+}
+
+func makeRequestOnScenarioRoute(unit ScenariosHandler, path string) *httptest.ResponseRecorder {
+	mux := bone.New()
+	mux.Delete("/api/v2/scenarios/:name", negroni.New(negroni.HandlerFunc(unit.DeleteScenario)))
+
+	request, _ := http.NewRequest("DELETE", path, nil)
+	response := httptest.NewRecorder()
+	mux.ServeHTTP(response, request)
+	return response
+}
+
+func unmarshalScenariosView(buffer *bytes.Buffer) (ScenariosView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return ScenariosView{}, err
+	}
+
+	var scenariosView ScenariosView
+
+	err = json.Unmarshal(body, &scenariosView)
+	if err != nil {
+		return ScenariosView{}, err
+	}
+
+	return scenariosView, nil
+}
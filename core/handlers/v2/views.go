@@ -24,10 +24,32 @@ type ModeView struct {
 	Mode string `json:"mode"`
 }
 
+type RouteView struct {
+	Destination string `json:"destination"`
+	Mode        string `json:"mode"`
+	Middleware  string `json:"middleware,omitempty"`
+}
+
+type RoutesView struct {
+	Routes []RouteView `json:"routes"`
+}
+
+type PassthroughRuleView struct {
+	Action     string `json:"action"`
+	Host       string `json:"host,omitempty"`
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	Method     string `json:"method,omitempty"`
+}
+
+type PassthroughView struct {
+	Rules []PassthroughRuleView `json:"rules"`
+}
+
 type HoverflyView struct {
 	DestinationView
 	MiddlewareView
 	ModeView
+	RoutesView
 	UsageView
 }
 
@@ -47,13 +69,50 @@ func (this SimulationView) GetValidationSchema() valid.Validator {
 					valid.ObjKV("scheme", valid.Optional(valid.String())),
 					valid.ObjKV("query", valid.Optional(valid.String())),
 					valid.ObjKV("body", valid.Optional(valid.String())),
+					valid.ObjKV("encodedBody", valid.Optional(valid.Boolean())),
 					valid.ObjKV("headers", valid.Optional(valid.Object())),
+					valid.ObjKV("headerOrder", valid.Optional(valid.Array(valid.ArrEach(valid.String())))),
+					valid.ObjKV("queryParams", valid.Optional(valid.Object())),
 				)),
+				valid.ObjKV("priority", valid.Optional(valid.Number())),
+				valid.ObjKV("scenario", valid.Optional(valid.String())),
+				valid.ObjKV("requiredScenarioState", valid.Optional(valid.String())),
+				valid.ObjKV("newScenarioState", valid.Optional(valid.String())),
 				valid.ObjKV("response", valid.Object(
 					valid.ObjKV("status", valid.Optional(valid.Number())),
 					valid.ObjKV("body", valid.Optional(valid.String())),
 					valid.ObjKV("encodedBody", valid.Optional(valid.Boolean())),
+					valid.ObjKV("templated", valid.Optional(valid.Boolean())),
+					valid.ObjKV("fault", valid.Optional(valid.Object(
+						valid.ObjKV("type", valid.String()),
+						valid.ObjKV("percentage", valid.Optional(valid.Number())),
+						valid.ObjKV("status", valid.Optional(valid.Number())),
+					))),
+					valid.ObjKV("delay", valid.Optional(valid.Object(
+						valid.ObjKV("delay", valid.Optional(valid.Number())),
+						valid.ObjKV("distribution", valid.Optional(valid.String())),
+						valid.ObjKV("min", valid.Optional(valid.Number())),
+						valid.ObjKV("max", valid.Optional(valid.Number())),
+						valid.ObjKV("mean", valid.Optional(valid.Number())),
+						valid.ObjKV("stdDev", valid.Optional(valid.Number())),
+						valid.ObjKV("median", valid.Optional(valid.Number())),
+						valid.ObjKV("p99", valid.Optional(valid.Number())),
+					))),
+					valid.ObjKV("events", valid.Optional(valid.Array(valid.ArrEach(valid.Object(
+						valid.ObjKV("time", valid.Number()),
+						valid.ObjKV("data", valid.String()),
+					))))),
+					valid.ObjKV("webSocketMessages", valid.Optional(valid.Array(valid.ArrEach(valid.Object(
+						valid.ObjKV("from", valid.String()),
+						valid.ObjKV("type", valid.String()),
+						valid.ObjKV("data", valid.String()),
+						valid.ObjKV("time", valid.Number()),
+						valid.ObjKV("matcher", valid.Optional(valid.String())),
+					))))),
 					valid.ObjKV("headers", valid.Optional(valid.Object())),
+					valid.ObjKV("headerOrder", valid.Optional(valid.Array(valid.ArrEach(valid.String())))),
+					valid.ObjKV("trailers", valid.Optional(valid.Object())),
+					valid.ObjKV("contentEncoding", valid.Optional(valid.String())),
 				)),
 			))))),
 			valid.ObjKV("globalActions", valid.Optional(valid.Object(
@@ -61,6 +120,29 @@ func (this SimulationView) GetValidationSchema() valid.Validator {
 					valid.ObjKV("urlPattern", valid.Optional(valid.String())),
 					valid.ObjKV("httpMethod", valid.Optional(valid.String())),
 					valid.ObjKV("delay", valid.Optional(valid.Number())),
+					valid.ObjKV("distribution", valid.Optional(valid.String())),
+					valid.ObjKV("min", valid.Optional(valid.Number())),
+					valid.ObjKV("max", valid.Optional(valid.Number())),
+					valid.ObjKV("mean", valid.Optional(valid.Number())),
+					valid.ObjKV("stdDev", valid.Optional(valid.Number())),
+					valid.ObjKV("median", valid.Optional(valid.Number())),
+					valid.ObjKV("p99", valid.Optional(valid.Number())),
+					valid.ObjKV("request", valid.Optional(valid.Object())),
+				))))),
+				valid.ObjKV("faults", valid.Optional(valid.Array(valid.ArrEach(valid.Object(
+					valid.ObjKV("urlPattern", valid.Optional(valid.String())),
+					valid.ObjKV("httpMethod", valid.Optional(valid.String())),
+					valid.ObjKV("type", valid.String()),
+					valid.ObjKV("percentage", valid.Optional(valid.Number())),
+					valid.ObjKV("status", valid.Optional(valid.Number())),
+				))))),
+				valid.ObjKV("throttles", valid.Optional(valid.Array(valid.ArrEach(valid.Object(
+					valid.ObjKV("urlPattern", valid.Optional(valid.String())),
+					valid.ObjKV("httpMethod", valid.Optional(valid.String())),
+					valid.ObjKV("bytesPerSecond", valid.Optional(valid.Number())),
+					valid.ObjKV("timeToFirstByte", valid.Optional(valid.Number())),
+					valid.ObjKV("chunkSize", valid.Optional(valid.Number())),
+					valid.ObjKV("chunkDelay", valid.Optional(valid.Number())),
 				))))),
 			))),
 		)),
@@ -76,8 +158,12 @@ type DataView struct {
 }
 
 type RequestResponsePairView struct {
-	Response ResponseDetailsView `json:"response"`
-	Request  RequestDetailsView  `json:"request"`
+	Response              ResponseDetailsView `json:"response"`
+	Request               RequestDetailsView  `json:"request"`
+	Priority              int                 `json:"priority,omitempty"`
+	Scenario              string              `json:"scenario,omitempty"`
+	RequiredScenarioState string              `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string              `json:"newScenarioState,omitempty"`
 }
 
 //Gets Response - required for interfaces.RequestResponsePairView
@@ -86,6 +172,20 @@ func (this RequestResponsePairView) GetResponse() interfaces.Response { return t
 //Gets Request - required for interfaces.RequestResponsePairView
 func (this RequestResponsePairView) GetRequest() interfaces.Request { return this.Request }
 
+// Gets Priority - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetPriority() int { return this.Priority }
+
+// Gets Scenario - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetScenario() string { return this.Scenario }
+
+// Gets RequiredScenarioState - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetRequiredScenarioState() string {
+	return this.RequiredScenarioState
+}
+
+// Gets NewScenarioState - required for interfaces.RequestResponsePairView
+func (this RequestResponsePairView) GetNewScenarioState() string { return this.NewScenarioState }
+
 // RequestDetailsView is used when marshalling and unmarshalling RequestDetails
 type RequestDetailsView struct {
 	RequestType *string             `json:"requestType"`
@@ -95,7 +195,10 @@ type RequestDetailsView struct {
 	Scheme      *string             `json:"scheme"`
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
+	EncodedBody bool                `json:"encodedBody,omitempty"`
 	Headers     map[string][]string `json:"headers"`
+	HeaderOrder []string            `json:"headerOrder,omitempty"`
+	QueryParams map[string][]string `json:"queryParams,omitempty"`
 }
 
 //Gets RequestType - required for interfaces.Request
@@ -119,17 +222,34 @@ func (this RequestDetailsView) GetQuery() *string { return this.Query }
 //Gets Body - required for interfaces.Request
 func (this RequestDetailsView) GetBody() *string { return this.Body }
 
+// Gets EncodedBody - required for interfaces.Request
+func (this RequestDetailsView) GetEncodedBody() bool { return this.EncodedBody }
+
 //Gets Headers - required for interfaces.Request
 func (this RequestDetailsView) GetHeaders() map[string][]string { return this.Headers }
 
+// Gets HeaderOrder - required for interfaces.Request
+func (this RequestDetailsView) GetHeaderOrder() []string { return this.HeaderOrder }
+
+// Gets QueryParams - required for interfaces.Request
+func (this RequestDetailsView) GetQueryParams() map[string][]string { return this.QueryParams }
+
 // ResponseDetailsView is used when marshalling and
 // unmarshalling requests. This struct's Body may be Base64
 // encoded based on the EncodedBody field.
 type ResponseDetailsView struct {
-	Status      int                 `json:"status"`
-	Body        string              `json:"body"`
-	EncodedBody bool                `json:"encodedBody"`
-	Headers     map[string][]string `json:"headers"`
+	Status            int                       `json:"status"`
+	Body              string                    `json:"body"`
+	EncodedBody       bool                      `json:"encodedBody"`
+	Headers           map[string][]string       `json:"headers"`
+	HeaderOrder       []string                  `json:"headerOrder,omitempty"`
+	Trailers          map[string][]string       `json:"trailers,omitempty"`
+	ContentEncoding   string                    `json:"contentEncoding,omitempty"`
+	Templated         bool                      `json:"templated,omitempty"`
+	Fault             *v1.FaultView             `json:"fault,omitempty"`
+	Delay             *v1.DelayView             `json:"delay,omitempty"`
+	Events            []v1.ResponseEventView    `json:"events,omitempty"`
+	WebSocketMessages []v1.WebSocketMessageView `json:"webSocketMessages,omitempty"`
 }
 
 //Gets Status - required for interfaces.Response
@@ -144,8 +264,73 @@ func (this ResponseDetailsView) GetEncodedBody() bool { return this.EncodedBody
 // Gets Headers - required for interfaces.Response
 func (this ResponseDetailsView) GetHeaders() map[string][]string { return this.Headers }
 
+// Gets HeaderOrder - required for interfaces.Response
+func (this ResponseDetailsView) GetHeaderOrder() []string { return this.HeaderOrder }
+
+// Gets Trailers - required for interfaces.Response
+func (this ResponseDetailsView) GetTrailers() map[string][]string { return this.Trailers }
+
+// Gets ContentEncoding - required for interfaces.Response
+func (this ResponseDetailsView) GetContentEncoding() string { return this.ContentEncoding }
+
+// Gets Templated - required for interfaces.Response
+func (this ResponseDetailsView) GetTemplated() bool { return this.Templated }
+
+// Gets Fault - required for interfaces.Response
+func (this ResponseDetailsView) GetFault() interfaces.Fault {
+	if this.Fault == nil {
+		return nil
+	}
+	return this.Fault
+}
+
+// Gets Delay - required for interfaces.Response
+func (this ResponseDetailsView) GetDelay() interfaces.Delay {
+	if this.Delay == nil {
+		return nil
+	}
+	return this.Delay
+}
+
+// Gets Events - required for interfaces.Response
+func (this ResponseDetailsView) GetEvents() []interfaces.ResponseEvent {
+	events := make([]interfaces.ResponseEvent, len(this.Events))
+	for i, event := range this.Events {
+		events[i] = event
+	}
+	return events
+}
+
+// Gets WebSocketMessages - required for interfaces.Response
+func (this ResponseDetailsView) GetWebSocketMessages() []interfaces.WebSocketMessage {
+	messages := make([]interfaces.WebSocketMessage, len(this.WebSocketMessages))
+	for i, message := range this.WebSocketMessages {
+		messages[i] = message
+	}
+	return messages
+}
+
 type GlobalActionsView struct {
-	Delays []v1.ResponseDelayView `json:"delays"`
+	Delays    []v1.ResponseDelayView `json:"delays"`
+	Faults    []ResponseFaultView    `json:"faults,omitempty"`
+	Throttles []ResponseThrottleView `json:"throttles,omitempty"`
+}
+
+type ResponseThrottleView struct {
+	UrlPattern      string `json:"urlPattern"`
+	HttpMethod      string `json:"httpMethod"`
+	BytesPerSecond  int    `json:"bytesPerSecond,omitempty"`
+	TimeToFirstByte int    `json:"timeToFirstByte,omitempty"`
+	ChunkSize       int    `json:"chunkSize,omitempty"`
+	ChunkDelay      int    `json:"chunkDelay,omitempty"`
+}
+
+type ResponseFaultView struct {
+	UrlPattern string `json:"urlPattern"`
+	HttpMethod string `json:"httpMethod"`
+	Type       string `json:"type"`
+	Percentage *int   `json:"percentage,omitempty"`
+	Status     int    `json:"status,omitempty"`
 }
 
 type MetaView struct {
@@ -153,3 +338,49 @@ type MetaView struct {
 	HoverflyVersion string `json:"hoverflyVersion"`
 	TimeExported    string `json:"timeExported"`
 }
+
+type HashConfigurationView struct {
+	IncludeQueryParams []string `json:"includeQueryParams"`
+	ExcludeQueryParams []string `json:"excludeQueryParams"`
+	IncludeHeaders     []string `json:"includeHeaders"`
+	ExcludeBody        bool     `json:"excludeBody"`
+	ExcludeBodyPaths   []string `json:"excludeBodyPaths"`
+}
+
+type ScenarioView struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type ScenariosView struct {
+	Scenarios []ScenarioView `json:"scenarios"`
+}
+
+type DifferenceView struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type ResponseDiffView struct {
+	Request     RequestDetailsView `json:"request"`
+	Time        string             `json:"time"`
+	Differences []DifferenceView   `json:"differences"`
+}
+
+type DiffView struct {
+	Diffs []ResponseDiffView `json:"diffs"`
+}
+
+type BodyReplacementView struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type RedactionView struct {
+	Mask             string                `json:"mask"`
+	DropHeaders      []string              `json:"dropHeaders"`
+	MaskHeaders      []string              `json:"maskHeaders"`
+	BodyReplacements []BodyReplacementView `json:"bodyReplacements"`
+	MaskBodyPaths    []string              `json:"maskBodyPaths"`
+}
@@ -0,0 +1,212 @@
+package hoverfly
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+)
+
+// maxHeaderBytes bounds how much of a response is held while looking for the end
+// of its headers
+const maxHeaderBytes = 1 << 20
+
+// headerRecordingConn notes the name of each response header line as it arrives,
+// as net/http canonicalises their casing and loses their order when parsing them
+type headerRecordingConn struct {
+	net.Conn
+	mu        sync.Mutex
+	recording bool
+	buffer    []byte
+	order     []string
+}
+
+// record starts looking for the headers of the next response read from the connection
+func (this *headerRecordingConn) record() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.recording = true
+	this.buffer = nil
+	this.order = nil
+}
+
+func (this *headerRecordingConn) Read(p []byte) (int, error) {
+	n, err := this.Conn.Read(p)
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.recording && n > 0 {
+		this.buffer = append(this.buffer, p[:n]...)
+		this.parse()
+	}
+	return n, err
+}
+
+func (this *headerRecordingConn) parse() {
+	for {
+		end := bytes.Index(this.buffer, []byte("\r\n\r\n"))
+		if end < 0 {
+			if len(this.buffer) > maxHeaderBytes {
+				this.recording = false
+				this.buffer = nil
+			}
+			return
+		}
+
+		lines := strings.Split(string(this.buffer[:end]), "\r\n")
+		this.buffer = this.buffer[end+4:]
+
+		// informational responses, such as 100 Continue, come before the real one
+		if status := strings.Fields(lines[0]); len(status) > 1 && strings.HasPrefix(status[1], "1") {
+			continue
+		}
+
+		for _, line := range lines[1:] {
+			if colon := strings.Index(line, ":"); colon > 0 {
+				this.order = append(this.order, line[:colon])
+			}
+		}
+		this.recording = false
+		this.buffer = nil
+		return
+	}
+}
+
+func (this *headerRecordingConn) headerOrder() []string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.order
+}
+
+// headerRecordingListener records the order and casing of the header lines of
+// each request read from the connections it accepts
+type headerRecordingListener struct {
+	net.Listener
+}
+
+func (this headerRecordingListener) Accept() (net.Conn, error) {
+	conn, err := this.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &headerRecordingConn{Conn: conn, recording: true}, nil
+}
+
+type requestHeaderKey struct{}
+
+// withRequestHeaderConn passes the connection a request was read from on through
+// the request context, so that the order of its header lines can be looked up.
+// Connections served over TLS are not recorded, as net/http has to be handed
+// the TLS connection itself.
+func withRequestHeaderConn(ctx context.Context, conn net.Conn) context.Context {
+	if recording, ok := conn.(*headerRecordingConn); ok {
+		return context.WithValue(ctx, requestHeaderKey{}, recording)
+	}
+	return ctx
+}
+
+func requestHeaderConn(req *http.Request) *headerRecordingConn {
+	conn, _ := req.Context().Value(requestHeaderKey{}).(*headerRecordingConn)
+	return conn
+}
+
+// requestHeaderOrder returns the names of the header lines of an HTTP/1.x request
+// as they were received, leaving out those net/http or goproxy have taken out of
+// the header, or nil when they were not recorded
+func requestHeaderOrder(req *http.Request) []string {
+	conn := requestHeaderConn(req)
+	if conn == nil || req.ProtoMajor != 1 {
+		return nil
+	}
+
+	var order []string
+	counts := map[string]int{}
+	for _, name := range conn.headerOrder() {
+		key := http.CanonicalHeaderKey(name)
+		if _, ok := req.Header[key]; ok {
+			order = append(order, name)
+			counts[key]++
+		}
+	}
+
+	// the lines recorded have to be those of this request
+	for key, values := range req.Header {
+		if counts[key] != len(values) {
+			return nil
+		}
+	}
+	return order
+}
+
+type headerRecordingKey struct{}
+
+type headerRecording struct {
+	conn *headerRecordingConn
+}
+
+// withHeaderRecording has the upstream connection a request is sent on record the
+// order and casing of the response headers
+func withHeaderRecording(req *http.Request) *http.Request {
+	recording := &headerRecording{}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if conn, ok := info.Conn.(*headerRecordingConn); ok {
+				conn.record()
+				recording.conn = conn
+			}
+		},
+	}
+
+	ctx := context.WithValue(httptrace.WithClientTrace(req.Context(), trace), headerRecordingKey{}, recording)
+	return req.WithContext(ctx)
+}
+
+// recordedHeaderOrder returns the names of the response header lines as they were
+// received, or nil when they were not recorded
+func recordedHeaderOrder(resp *http.Response) []string {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+
+	recording, ok := resp.Request.Context().Value(headerRecordingKey{}).(*headerRecording)
+	if !ok || recording.conn == nil {
+		return nil
+	}
+	return recording.conn.headerOrder()
+}
+
+// headersInterpretedByServer are written by net/http by their canonical name, as
+// it looks them up to frame the response
+var headersInterpretedByServer = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Date":              true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+}
+
+// applyHeaderCasing renames each header to the casing it was recorded with, which
+// net/http writes as it is given. net/http always writes the headers sorted by
+// name, so the recorded order itself is not kept.
+func applyHeaderCasing(header http.Header, order []string) {
+	renamed := map[string]bool{}
+	for _, name := range order {
+		key := http.CanonicalHeaderKey(name)
+		if name == key || renamed[key] || headersInterpretedByServer[key] {
+			continue
+		}
+		renamed[key] = true
+
+		if values, ok := header[key]; ok {
+			delete(header, key)
+			header[name] = values
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package hoverfly
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const legacyHeaders = "x-LEGACY-Header: a\r\n" +
+	"Set-Cookie: b=2\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Set-Cookie: a=1\r\n" +
+	"Content-Length: 2\r\n"
+
+// legacyServer writes its headers in an order and casing net/http would not
+func legacyServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buffer, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+
+		buffer.WriteString("HTTP/1.1 200 OK\r\n" + legacyHeaders + "\r\nok")
+		buffer.Flush()
+	}))
+}
+
+func rawRequest(server *httptest.Server, target, host string) string {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target, host)
+	response, err := ioutil.ReadAll(conn)
+	Expect(err).To(BeNil())
+	return string(response)
+}
+
+func TestApplyHeaderCasing(t *testing.T) {
+	RegisterTestingT(t)
+
+	header := http.Header{
+		"Set-Cookie":     []string{"b=2", "a=1"},
+		"Content-Length": []string{"2"},
+		"X-Legacy":       []string{"a"},
+	}
+
+	applyHeaderCasing(header, []string{"set-cookie", "content-length", "x-LEGACY", "Set-Cookie", "X-Missing"})
+
+	Expect(header).To(Equal(http.Header{
+		"set-cookie":     []string{"b=2", "a=1"},
+		"Content-Length": []string{"2"},
+		"x-LEGACY":       []string{"a"},
+	}))
+}
+
+func TestHoverfly_ReplaysTheRecordedHeaderCasing(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := legacyServer()
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	host := upstream.Listener.Addr().String()
+
+	unit.Cfg.SetMode("capture")
+	Expect(rawRequest(proxy, upstream.URL+"/legacy", host)).To(HaveSuffix("ok"))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(simulation.RequestResponsePairs[0].Response.HeaderOrder).To(Equal([]string{
+		"x-LEGACY-Header", "Set-Cookie", "Content-Type", "Set-Cookie", "Content-Length",
+	}))
+
+	unit.Cfg.SetMode("simulate")
+	upstream.Close()
+
+	// net/http writes the headers sorted by name, with the casing as recorded
+	replayedHeaders := "Content-Length: 2\r\n" +
+		"Content-Type: text/plain\r\n"
+	for _, server := range []*httptest.Server{proxy, httptest.NewServer(withClientWriter(NewWebserverProxy(unit)))} {
+		target := upstream.URL + "/legacy"
+		if server != proxy {
+			defer server.Close()
+			target = "/legacy"
+		}
+
+		response := rawRequest(server, target, host)
+		Expect(response).To(HavePrefix("HTTP/1.1 200 OK\r\n"))
+		Expect(response).To(ContainSubstring(replayedHeaders))
+		Expect(response).To(ContainSubstring("Set-Cookie: b=2\r\nSet-Cookie: a=1\r\n"))
+		Expect(response).To(ContainSubstring("x-LEGACY-Header: a\r\n"))
+		Expect(response).To(ContainSubstring("Date: "))
+		Expect(response).To(HaveSuffix("\r\n\r\nok"))
+	}
+}
+
+func TestHoverfly_KeepsTheClientConnectionAliveWhenReplayingHeaderCasing(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := legacyServer()
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	host := upstream.Listener.Addr().String()
+
+	unit.Cfg.SetMode("capture")
+	Expect(rawRequest(proxy, upstream.URL+"/legacy", host)).To(HaveSuffix("ok"))
+
+	unit.Cfg.SetMode("simulate")
+	upstream.Close()
+
+	conn, err := net.Dial("tcp", proxy.Listener.Addr().String())
+	Expect(err).To(BeNil())
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for i := 0; i < 2; i++ {
+		fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\n\r\n", upstream.URL+"/legacy", host)
+
+		response, err := http.ReadResponse(reader, nil)
+		Expect(err).To(BeNil())
+		Expect(response.Close).To(BeFalse())
+		Expect(response.Header["Set-Cookie"]).To(Equal([]string{"b=2", "a=1"}))
+
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).To(BeNil())
+		Expect(string(body)).To(Equal("ok"))
+	}
+}
+
+func TestHoverfly_RecordsTheRequestHeaderOrderAndCasing(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := legacyServer()
+	defer upstream.Close()
+
+	proxy := httptest.NewUnstartedServer(withClientWriter(NewProxy(unit)))
+	proxy.Listener = headerRecordingListener{proxy.Listener}
+	proxy.Config.ConnContext = withRequestHeaderConn
+	proxy.Start()
+	defer proxy.Close()
+
+	unit.Cfg.SetMode("capture")
+
+	conn, err := net.Dial("tcp", proxy.Listener.Addr().String())
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nx-LEGACY-Header: a\r\nHost: %s\r\nAccept: b\r\nx-legacy-header: c\r\nConnection: close\r\n\r\n",
+		upstream.URL+"/legacy", upstream.Listener.Addr().String())
+	_, err = ioutil.ReadAll(conn)
+	Expect(err).To(BeNil())
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(simulation.RequestResponsePairs[0].Request.HeaderOrder).To(Equal([]string{
+		"x-LEGACY-Header", "Accept", "x-legacy-header", "Connection",
+	}))
+}
+
+func TestRequestHeaderOrder_IsLeftOutWhenItDoesNotMatchTheRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	conn := &headerRecordingConn{order: []string{"Accept", "x-Other"}}
+	req, _ := http.NewRequest("GET", "http://hoverfly.io", nil)
+	req.Header.Set("Accept", "a")
+	req.Header.Set("X-Custom", "b")
+	req = req.WithContext(context.WithValue(req.Context(), requestHeaderKey{}, conn))
+
+	Expect(requestHeaderOrder(req)).To(BeNil())
+
+	conn.order = []string{"X-CUSTOM", "Accept"}
+	Expect(requestHeaderOrder(req)).To(Equal([]string{"X-CUSTOM", "Accept"}))
+}
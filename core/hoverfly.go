@@ -2,7 +2,6 @@ package hoverfly
 
 import (
 	"bytes"
-	"crypto/tls"
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	authBackend "github.com/SpectoLabs/hoverfly/core/authentication/backends"
@@ -10,6 +9,7 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/metrics"
 	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/templating"
 	"github.com/rusenask/goproxy"
 	"io/ioutil"
 	"net"
@@ -30,6 +30,14 @@ const ModifyMode = "modify"
 // CaptureMode - requests are captured and stored in cache
 const CaptureMode = "capture"
 
+// SpyMode - requests are simulated when they match, anything else is forwarded
+// to the real destination
+const SpyMode = "spy"
+
+// DiffMode - requests are forwarded to their destination and the responses
+// compared with the simulation
+const DiffMode = "diff"
+
 // orPanic - wrapper for logging errors
 func orPanic(err error) {
 	if err != nil {
@@ -50,7 +58,10 @@ type Hoverfly struct {
 	Counter        *metrics.CounterByMode
 	Hooks          ActionTypeHooks
 
-	ResponseDelays models.ResponseDelays
+	ResponseDelays    models.ResponseDelays
+	ResponseFaults    models.ResponseFaultList
+	ResponseThrottles models.ResponseThrottleList
+	ResponseDiffs     *models.ResponseDiffList
 
 	Proxy *goproxy.ProxyHttpServer
 	SL    *StoppableListener
@@ -60,21 +71,27 @@ type Hoverfly struct {
 // GetNewHoverfly returns a configured ProxyHttpServer and DBClient
 func GetNewHoverfly(cfg *Configuration, requestCache, metadataCache cache.Cache, authentication authBackend.Authentication) *Hoverfly {
 	requestMatcher := matching.RequestMatcher{
-		RequestCache:  requestCache,
-		TemplateStore: matching.RequestTemplateStore{},
-		Webserver:     &cfg.Webserver,
+		RequestCache:      requestCache,
+		TemplateStore:     matching.RequestTemplateStore{},
+		Webserver:         &cfg.Webserver,
+		Scenarios:         matching.NewScenarioState(),
+		HashConfiguration: loadHashConfiguration(metadataCache),
+		Redaction:         loadRedaction(metadataCache),
 	}
 
 	h := &Hoverfly{
-		RequestCache:   requestCache,
-		MetadataCache:  metadataCache,
-		Authentication: authentication,
-		HTTP:           GetDefaultHoverflyHTTPClient(cfg.TLSVerification),
-		Cfg:            cfg,
-		Counter:        metrics.NewModeCounter([]string{SimulateMode, SynthesizeMode, ModifyMode, CaptureMode}),
-		Hooks:          make(ActionTypeHooks),
-		ResponseDelays: &models.ResponseDelayList{},
-		RequestMatcher: requestMatcher,
+		RequestCache:      requestCache,
+		MetadataCache:     metadataCache,
+		Authentication:    authentication,
+		HTTP:              GetDefaultHoverflyHTTPClient(cfg.TLSVerification),
+		Cfg:               cfg,
+		Counter:           metrics.NewModeCounter([]string{SimulateMode, SynthesizeMode, ModifyMode, CaptureMode, SpyMode, DiffMode}),
+		Hooks:             make(ActionTypeHooks),
+		ResponseDelays:    &models.ResponseDelayList{},
+		ResponseFaults:    models.ResponseFaultList{},
+		ResponseThrottles: models.ResponseThrottleList{},
+		ResponseDiffs:     &models.ResponseDiffList{},
+		RequestMatcher:    requestMatcher,
 	}
 	return h
 }
@@ -82,15 +99,23 @@ func GetNewHoverfly(cfg *Configuration, requestCache, metadataCache cache.Cache,
 func GetDefaultHoverflyHTTPClient(tlsVerification bool) *http.Client {
 	return &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
-	}, Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: tlsVerification},
-	}}
+	}, Transport: newUpstreamTransport(tlsVerification)}
 }
 
 // StartProxy - starts proxy with current configuration, this method is non blocking.
 func (hf *Hoverfly) StartProxy() error {
 
-	rebuildHashes(hf.RequestCache, hf.Cfg.Webserver)
+	if err := rebuildHashes(hf.RequestCache, hf.Cfg.Webserver, hf.RequestMatcher.HashConfiguration, hf.RequestMatcher.Redaction); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to rehash recorded requests, they have been left as they were")
+	}
+
+	if err := hf.RequestMatcher.RegisterScenarios(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to register scenarios of recorded requests")
+	}
 
 	if hf.Cfg.ProxyPort == "" {
 		return fmt.Errorf("Proxy port is not set!")
@@ -119,7 +144,10 @@ func (hf *Hoverfly) StartProxy() error {
 		return err
 	}
 	hf.SL = sl
-	server := http.Server{}
+	server := http.Server{
+		Protocols:   serverProtocols(),
+		ConnContext: withRequestHeaderConn,
+	}
 
 	hf.Cfg.ProxyControlWG.Add(1)
 
@@ -129,8 +157,8 @@ func (hf *Hoverfly) StartProxy() error {
 			hf.Cfg.ProxyControlWG.Done()
 		}()
 		log.Info("serving proxy")
-		server.Handler = hf.Proxy
-		log.Warn(server.Serve(sl))
+		server.Handler = withClientWriter(hf.Proxy)
+		log.Warn(server.Serve(headerRecordingListener{sl}))
 	}()
 
 	return nil
@@ -152,13 +180,16 @@ func hoverflyError(req *http.Request, err error, msg string, statusCode int) *ht
 // returns HTTP response.
 func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 	var response *http.Response
+	var simulated *models.ResponseDetails
 
-	mode := hf.Cfg.GetMode()
+	route := hf.routeFor(req)
+	mode, middleware := route.Mode, route.Middleware
 
 	requestDetails, err := models.NewRequestDetailsFromHttpRequest(req)
 	if err != nil {
 		return hoverflyError(req, err, "Could not interpret HTTP request", http.StatusServiceUnavailable)
 	}
+	requestDetails.HeaderOrder = requestHeaderOrder(req)
 
 	if mode == CaptureMode {
 		var err error
@@ -169,7 +200,7 @@ func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 		}
 		log.WithFields(log.Fields{
 			"mode":        mode,
-			"middleware":  hf.Cfg.Middleware,
+			"middleware":  middleware,
 			"path":        req.URL.Path,
 			"rawQuery":    req.URL.RawQuery,
 			"method":      req.Method,
@@ -178,9 +209,19 @@ func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 
 		return response
 
+	} else if mode == DiffMode {
+		var err error
+		response, err = hf.diffRequest(req, requestDetails)
+
+		if err != nil {
+			return hoverflyError(req, err, "Could not forward request", http.StatusServiceUnavailable)
+		}
+
+		return response
+
 	} else if mode == SynthesizeMode {
 		var err error
-		response, err = SynthesizeResponse(req, requestDetails, hf.Cfg.Middleware)
+		response, err = SynthesizeResponse(req, requestDetails, middleware)
 
 		if err != nil {
 			return hoverflyError(req, err, "Could not create synthetic response!", http.StatusServiceUnavailable)
@@ -188,7 +229,7 @@ func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 
 		log.WithFields(log.Fields{
 			"mode":        mode,
-			"middleware":  hf.Cfg.Middleware,
+			"middleware":  middleware,
 			"path":        req.URL.Path,
 			"rawQuery":    req.URL.RawQuery,
 			"method":      req.Method,
@@ -197,29 +238,49 @@ func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 
 	} else if mode == ModifyMode {
 		var err error
-		response, err = hf.modifyRequestResponse(req, requestDetails, hf.Cfg.Middleware)
+		response, err = hf.modifyRequestResponse(req, requestDetails, middleware)
 
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error":      err.Error(),
-				"middleware": hf.Cfg.Middleware,
+				"middleware": middleware,
 			}).Error("Got error when performing request modification")
-			return hoverflyError(req, err, fmt.Sprintf("Middleware (%s) failed or something else happened!", hf.Cfg.Middleware), http.StatusServiceUnavailable)
+			return hoverflyError(req, err, fmt.Sprintf("Middleware (%s) failed or something else happened!", middleware), http.StatusServiceUnavailable)
+		}
+
+	} else if mode == SpyMode {
+		var err *matching.MatchingError
+		response, simulated, err = hf.getResponse(req, requestDetails)
+		if err != nil && err.StatusCode == http.StatusPreconditionFailed {
+			return hf.spyRequest(req)
+		} else if err != nil {
+			return hoverflyError(req, err, "There was an error when matching", err.StatusCode)
 		}
 
 	} else {
 		var err *matching.MatchingError
-		response, err = hf.getResponse(req, requestDetails)
+		response, simulated, err = hf.getResponse(req, requestDetails)
 		if err != nil {
-			return hoverflyError(req, err, err.Error(), err.StatusCode)
+			return hoverflyError(req, err, "There was an error when matching", err.StatusCode)
 		}
 	}
 
-	respDelay := hf.ResponseDelays.GetDelay(requestDetails)
+	respDelay := hf.delayFor(requestDetails, simulated)
 	if respDelay != nil {
 		respDelay.Execute()
 	}
 
+	// faults are injected into any simulated response, in spy mode as well
+	if simulated != nil {
+		if fault := hf.faultFor(requestDetails, simulated); fault.Triggered() {
+			response = injectFault(req, response, fault)
+		}
+	}
+
+	if throttle := hf.ResponseThrottles.GetThrottle(requestDetails); throttle != nil {
+		response = throttleResponse(response, throttle)
+	}
+
 	return response
 }
 
@@ -228,6 +289,33 @@ func (hf *Hoverfly) AddHook(hook Hook) {
 	hf.Hooks.Add(hook)
 }
 
+// spyRequest forwards a request the simulation has no match for to its real
+// destination, capturing it as well when configured to
+func (hf *Hoverfly) spyRequest(req *http.Request) *http.Response {
+	var resp *http.Response
+	var err error
+	if hf.Cfg.SpyCapture {
+		resp, err = hf.captureRequest(req)
+	} else {
+		_, resp, err = hf.doRequest(req)
+	}
+
+	if err != nil {
+		return hoverflyError(req, err, "Could not forward request", http.StatusServiceUnavailable)
+	}
+
+	log.WithFields(log.Fields{
+		"mode":        SpyMode,
+		"captured":    hf.Cfg.SpyCapture,
+		"path":        req.URL.Path,
+		"rawQuery":    req.URL.RawQuery,
+		"method":      req.Method,
+		"destination": req.Host,
+	}).Info("request did not match, forwarded to destination")
+
+	return resp
+}
+
 // captureRequest saves request for later playback
 func (hf *Hoverfly) captureRequest(req *http.Request) (*http.Response, error) {
 
@@ -254,18 +342,27 @@ func (hf *Hoverfly) captureRequest(req *http.Request) (*http.Response, error) {
 	// forwarding request
 	req.Body = ioutil.NopCloser(bytes.NewBuffer(reqBody))
 
-	req, resp, err := hf.doRequest(req)
+	req, resp, err := hf.doRequest(withHeaderRecording(req))
 
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err.Error(),
 			"mode":  "capture",
 		}).Error("Got error when reading body after being modified by middleware")
+		return nil, err
 	}
 
 	reqBody, err = ioutil.ReadAll(req.Body)
 	req.Body = ioutil.NopCloser(bytes.NewBuffer(reqBody))
 
+	if err == nil && isStreamingResponse(resp) {
+		// a stream is saved once it ends, it may never end if read up front
+		resp.Body = newRecordingBody(resp, func(respBody []byte, events []models.ResponseEvent) {
+			hf.saveWithEvents(req, reqBody, resp, respBody, events)
+		})
+		return resp, nil
+	}
+
 	if err == nil {
 		respBody, err := extractBody(resp)
 
@@ -293,7 +390,8 @@ func (hf *Hoverfly) doRequest(request *http.Request) (*http.Request, *http.Respo
 	// We can't have this set. And it only contains "/pkg/net/http/" anyway
 	request.RequestURI = ""
 
-	if hf.Cfg.Middleware != "" {
+	route := hf.routeFor(request)
+	if route.Middleware != "" {
 		// middleware is provided, modifying request
 		var requestResponsePair models.RequestResponsePair
 
@@ -304,11 +402,11 @@ func (hf *Hoverfly) doRequest(request *http.Request) (*http.Request, *http.Respo
 		requestResponsePair.Request = rd
 
 		c := NewConstructor(request, requestResponsePair)
-		err = c.ApplyMiddleware(hf.Cfg.Middleware)
+		err = c.ApplyMiddleware(route.Middleware)
 
 		if err != nil {
 			log.WithFields(log.Fields{
-				"mode":   hf.Cfg.Mode,
+				"mode":   route.Mode,
 				"error":  err.Error(),
 				"host":   request.Host,
 				"method": request.Method,
@@ -334,7 +432,7 @@ func (hf *Hoverfly) doRequest(request *http.Request) (*http.Request, *http.Respo
 
 	if err != nil {
 		log.WithFields(log.Fields{
-			"mode":   hf.Cfg.Mode,
+			"mode":   route.Mode,
 			"error":  err.Error(),
 			"host":   request.Host,
 			"method": request.Method,
@@ -344,7 +442,7 @@ func (hf *Hoverfly) doRequest(request *http.Request) (*http.Request, *http.Respo
 	}
 
 	log.WithFields(log.Fields{
-		"mode":   hf.Cfg.Mode,
+		"mode":   route.Mode,
 		"host":   request.Host,
 		"method": request.Method,
 		"path":   request.URL.Path,
@@ -356,12 +454,31 @@ func (hf *Hoverfly) doRequest(request *http.Request) (*http.Request, *http.Respo
 
 }
 
-// getResponse returns stored response from cache
-func (hf *Hoverfly) getResponse(req *http.Request, requestDetails models.RequestDetails) (*http.Response, *matching.MatchingError) {
+// getResponse returns stored response from cache, along with the details of the
+// response it was made from
+func (hf *Hoverfly) getResponse(req *http.Request, requestDetails models.RequestDetails) (*http.Response, *models.ResponseDetails, *matching.MatchingError) {
 
 	responseDetails, matchErr := hf.RequestMatcher.GetResponse(&requestDetails)
 	if matchErr != nil {
-		return nil, matchErr
+		return nil, nil, matchErr
+	}
+
+	if responseDetails.Templated {
+		rendered, err := templating.RenderResponse(*responseDetails, requestDetails)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":       err.Error(),
+				"path":        requestDetails.Path,
+				"method":      requestDetails.Method,
+				"destination": requestDetails.Destination,
+			}).Error("Failed to render response template")
+
+			return nil, nil, &matching.MatchingError{
+				StatusCode:  http.StatusInternalServerError,
+				Description: "Failed to render response template: " + err.Error(),
+			}
+		}
+		responseDetails = &rendered
 	}
 
 	pair := &models.RequestResponsePair{
@@ -370,11 +487,33 @@ func (hf *Hoverfly) getResponse(req *http.Request, requestDetails models.Request
 	}
 
 	c := NewConstructor(req, *pair)
-	if hf.Cfg.Middleware != "" {
-		_ = c.ApplyMiddleware(hf.Cfg.Middleware)
+	if middleware := hf.routeFor(req).Middleware; middleware != "" {
+		_ = c.ApplyMiddleware(middleware)
 	}
 
-	return c.ReconstructResponse(), nil
+	response := c.ReconstructResponse()
+	if responseDetails.ContentEncoding != "" {
+		encodeResponse(req, response, responseDetails.ContentEncoding)
+	}
+	if writer := clientWriterFor(req); writer != nil {
+		writer.headerOrder = responseDetails.HeaderOrder
+	}
+	if len(responseDetails.Events) > 0 {
+		response.Body = models.NewEventStreamBody(responseDetails.Events, hf.Cfg.StreamSpeed)
+		response.ContentLength = -1
+		response.Header.Del("Content-Length")
+	}
+
+	return response, responseDetails, nil
+}
+
+// delayFor returns the delay for a response. A delay attached to the simulated
+// response takes the place of any global delay for the request.
+func (hf *Hoverfly) delayFor(requestDetails models.RequestDetails, simulated *models.ResponseDetails) *models.ResponseDelay {
+	if simulated != nil && simulated.Delay != nil {
+		return simulated.Delay
+	}
+	return hf.ResponseDelays.GetDelay(requestDetails)
 }
 
 // modifyRequestResponse modifies outgoing request and then modifies incoming response, neither request nor response
@@ -437,59 +576,94 @@ func (hf *Hoverfly) modifyRequestResponse(req *http.Request, requestDetails mode
 
 // save gets request fingerprint, extracts request body, status code and headers, then saves it to cache
 func (hf *Hoverfly) save(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) {
+	hf.saveWithEvents(req, reqBody, resp, respBody, nil)
+}
+
+// saveWithEvents saves a response along with the events it was streamed as
+func (hf *Hoverfly) saveWithEvents(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, events []models.ResponseEvent) {
 
 	if resp == nil {
 		resp = emptyResp
 	} else {
 		responseObj := models.ResponseDetails{
-			Status:  resp.StatusCode,
-			Body:    string(respBody),
-			Headers: resp.Header,
+			Status:      resp.StatusCode,
+			Body:        string(respBody),
+			Headers:     resp.Header,
+			HeaderOrder: recordedHeaderOrder(resp),
+			Trailers:    resp.Trailer,
+			Events:      events,
 		}
 
-		requestObj := models.RequestDetails{
-			Path:        req.URL.Path,
-			Method:      req.Method,
-			Destination: req.Host,
-			Scheme:      req.URL.Scheme,
-			Query:       req.URL.RawQuery,
-			Body:        string(reqBody),
-			Headers:     req.Header,
+		// compressed bodies are stored readable, and encoded again on playback
+		if events == nil {
+			if body, coding := models.DecodeContent(resp.Header, respBody); coding != "" {
+				responseObj.Body = string(body)
+				responseObj.Headers = withoutContentEncoding(resp.Header)
+				responseObj.ContentEncoding = coding
+			}
 		}
 
-		pair := models.RequestResponsePair{
-			Response: responseObj,
-			Request:  requestObj,
-		}
+		hf.savePair(req, reqBody, responseObj)
+	}
+}
 
-		err := hf.RequestMatcher.SaveRequestResponsePair(&pair)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"error": err.Error(),
-			}).Error("Failed to save payload")
-		}
+// savePair saves a captured response against the request that produced it
+func (hf *Hoverfly) savePair(req *http.Request, reqBody []byte, responseObj models.ResponseDetails) {
+	requestObj := models.RequestDetails{
+		Path:        req.URL.Path,
+		Method:      req.Method,
+		Destination: req.Host,
+		Scheme:      req.URL.Scheme,
+		Query:       req.URL.RawQuery,
+		Body:        string(reqBody),
+		Headers:     req.Header,
+		HeaderOrder: requestHeaderOrder(req),
+	}
+	if body, coding := models.DecodeContent(req.Header, reqBody); coding != "" {
+		requestObj.Body = string(body)
+	}
 
-		pairBytes, err := pair.Encode()
-		if err != nil {
+	// keyed on the request as it was sent, the matcher redacts what it stores
+	pair := models.RequestResponsePair{
+		Response: responseObj,
+		Request:  requestObj,
+	}
+
+	var err error
+	if hf.Cfg.CaptureSequence != "" {
+		hf.mu.Lock()
+		err = hf.RequestMatcher.SaveRequestResponsePairInSequence(&pair, hf.Cfg.CaptureSequence == CaptureSequenceLoop)
+		hf.mu.Unlock()
+	} else {
+		err = hf.RequestMatcher.SaveRequestResponsePair(&pair)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to save payload")
+	}
+
+	redacted := hf.RequestMatcher.Redaction.Apply(pair)
+	pairBytes, err := redacted.Encode()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to serialize payload")
+	} else {
+		// hook
+		var en Entry
+		en.ActionType = ActionTypeRequestCaptured
+		en.Message = "captured"
+		en.Time = time.Now()
+		en.Data = pairBytes
+
+		if err := hf.Hooks.Fire(ActionTypeRequestCaptured, &en); err != nil {
 			log.WithFields(log.Fields{
-				"error": err.Error(),
-			}).Error("Failed to serialize payload")
-		} else {
-			// hook
-			var en Entry
-			en.ActionType = ActionTypeRequestCaptured
-			en.Message = "captured"
-			en.Time = time.Now()
-			en.Data = pairBytes
-
-			if err := hf.Hooks.Fire(ActionTypeRequestCaptured, &en); err != nil {
-				log.WithFields(log.Fields{
-					"error":      err.Error(),
-					"message":    en.Message,
-					"actionType": ActionTypeRequestCaptured,
-				}).Error("failed to fire hook")
-			}
+				"error":      err.Error(),
+				"message":    en.Message,
+				"actionType": ActionTypeRequestCaptured,
+			}).Error("failed to fire hook")
 		}
-
 	}
+
 }
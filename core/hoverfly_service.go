@@ -3,6 +3,7 @@ package hoverfly
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -10,6 +11,7 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/interfaces"
+	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/metrics"
 	"github.com/SpectoLabs/hoverfly/core/models"
 )
@@ -37,14 +39,16 @@ func (this Hoverfly) GetMode() string {
 	return this.Cfg.Mode
 }
 
-func (this *Hoverfly) SetMode(mode string) error {
-	availableModes := map[string]bool{
-		"simulate":   true,
-		"capture":    true,
-		"modify":     true,
-		"synthesize": true,
-	}
+var availableModes = map[string]bool{
+	SimulateMode:   true,
+	CaptureMode:    true,
+	ModifyMode:     true,
+	SynthesizeMode: true,
+	SpyMode:        true,
+	DiffMode:       true,
+}
 
+func (this *Hoverfly) SetMode(mode string) error {
 	if mode == "" || !availableModes[mode] {
 		log.Error("Can't change mode to \"%d\"", mode)
 		return fmt.Errorf("Not a valid mode")
@@ -122,27 +126,221 @@ func (hf *Hoverfly) GetResponseDelays() v1.ResponseDelayPayloadView {
 }
 
 func (hf *Hoverfly) SetResponseDelays(payloadView v1.ResponseDelayPayloadView) error {
-	err := models.ValidateResponseDelayPayload(payloadView)
+	responseDelays, err := matching.NewResponseDelayListFromView(payloadView, &hf.Cfg.Webserver)
+	if err != nil {
+		return err
+	}
+
+	hf.ResponseDelays = responseDelays
+	return nil
+}
+
+func (hf *Hoverfly) DeleteResponseDelays() {
+	hf.ResponseDelays = &models.ResponseDelayList{}
+}
+
+func (hf *Hoverfly) SetResponseFaults(faultViews []v2.ResponseFaultView) error {
+	responseFaults, err := models.NewResponseFaultListFromView(faultViews)
+	if err != nil {
+		return err
+	}
+
+	hf.ResponseFaults = responseFaults
+	return nil
+}
+
+func (hf *Hoverfly) DeleteResponseFaults() {
+	hf.ResponseFaults = models.ResponseFaultList{}
+}
+
+func (hf *Hoverfly) SetResponseThrottles(throttleViews []v2.ResponseThrottleView) error {
+	responseThrottles, err := models.NewResponseThrottleListFromView(throttleViews)
+	if err != nil {
+		return err
+	}
+
+	hf.ResponseThrottles = responseThrottles
+	return nil
+}
+
+func (hf *Hoverfly) DeleteResponseThrottles() {
+	hf.ResponseThrottles = models.ResponseThrottleList{}
+}
+
+func (hf *Hoverfly) GetHashConfiguration() v2.HashConfigurationView {
+	return hf.RequestMatcher.HashConfiguration.ConvertToHashConfigurationView()
+}
+
+// SetHashConfiguration - changes which parts of a request are used to store and find recorded pairs,
+// pairs which are already in the cache are rehashed. The configuration is rejected when different
+// pairs would end up with the same key.
+func (hf *Hoverfly) SetHashConfiguration(hashConfigurationView v2.HashConfigurationView) error {
+	hashConfiguration, err := models.NewHashConfigurationFromView(hashConfigurationView)
+	if err != nil {
+		return err
+	}
+
+	if err := rebuildHashes(hf.RequestCache, hf.Cfg.Webserver, hashConfiguration, hf.RequestMatcher.Redaction); err != nil {
+		return err
+	}
+	hf.RequestMatcher.HashConfiguration = hashConfiguration
+
+	if err := hf.RequestMatcher.RegisterScenarios(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to register scenarios of rehashed requests")
+	}
+
+	if err := saveHashConfiguration(hf.MetadataCache, hashConfiguration); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to save hash configuration")
+	}
+	return nil
+}
+
+func (hf *Hoverfly) GetRedaction() v2.RedactionView {
+	return hf.RequestMatcher.Redaction.ConvertToRedactionView()
+}
+
+// SetRedaction - changes what is removed from requests and responses before
+// they are stored or exported. As requests are hashed once redacted, pairs which
+// are already in the cache are rehashed.
+func (hf *Hoverfly) SetRedaction(redactionView v2.RedactionView) error {
+	redaction, err := models.NewRedactionFromView(redactionView)
 	if err != nil {
 		return err
 	}
 
-	var responseDelays models.ResponseDelayList
+	if err := rebuildHashes(hf.RequestCache, hf.Cfg.Webserver, hf.RequestMatcher.HashConfiguration, redaction); err != nil {
+		return err
+	}
+	hf.RequestMatcher.Redaction = redaction
+
+	if err := hf.RequestMatcher.RegisterScenarios(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to register scenarios of rehashed requests")
+	}
 
-	for _, responseDelayView := range payloadView.Data {
-		responseDelays = append(responseDelays, models.ResponseDelay{
-			UrlPattern: responseDelayView.UrlPattern,
-			HttpMethod: responseDelayView.HttpMethod,
-			Delay:      responseDelayView.Delay,
+	if err := saveRedaction(hf.MetadataCache, redaction); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to save redaction")
+	}
+	return nil
+}
+
+// GetRoutes returns the mode and middleware of each destination with its own
+func (hf *Hoverfly) GetRoutes() v2.RoutesView {
+	routes := []v2.RouteView{}
+	for _, route := range hf.Cfg.GetRoutes() {
+		routes = append(routes, v2.RouteView{
+			Destination: route.Destination,
+			Mode:        route.Mode,
+			Middleware:  route.Middleware,
 		})
 	}
+	return v2.RoutesView{Routes: routes}
+}
+
+// SetRoutes replaces the routes, which are only used for requests to a
+// destination Hoverfly intercepts
+func (hf *Hoverfly) SetRoutes(routesView v2.RoutesView) error {
+	var routes []Route
+	for _, routeView := range routesView.Routes {
+		route, err := NewRoute(routeView.Destination, routeView.Mode, routeView.Middleware)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, route)
+	}
 
-	hf.ResponseDelays = &responseDelays
+	if hf.Cfg.Webserver && len(routes) > 0 {
+		return fmt.Errorf("Can't route destinations to other modes when configured as a webserver")
+	}
+
+	hf.Cfg.SetRoutes(routes)
 	return nil
 }
 
-func (hf *Hoverfly) DeleteResponseDelays() {
-	hf.ResponseDelays = &models.ResponseDelayList{}
+// GetPassthroughRules returns the rules deciding which requests are processed
+func (hf *Hoverfly) GetPassthroughRules() v2.PassthroughView {
+	rules := []v2.PassthroughRuleView{}
+	for _, rule := range hf.Cfg.GetPassthroughRules() {
+		rules = append(rules, v2.PassthroughRuleView{
+			Action:     rule.Action,
+			Host:       rule.Host,
+			PathPrefix: rule.PathPrefix,
+			Method:     rule.Method,
+		})
+	}
+	return v2.PassthroughView{Rules: rules}
+}
+
+// SetPassthroughRules replaces the rules deciding which requests are processed
+func (hf *Hoverfly) SetPassthroughRules(passthroughView v2.PassthroughView) error {
+	var rules []PassthroughRule
+	for _, ruleView := range passthroughView.Rules {
+		rule, err := NewPassthroughRule(ruleView.Action, ruleView.Host, ruleView.PathPrefix, ruleView.Method)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+
+	hf.Cfg.SetPassthroughRules(rules)
+	return nil
+}
+
+// GetScenarios returns the state of every scenario used by a recorded request or template
+func (hf *Hoverfly) GetScenarios() v2.ScenariosView {
+	states := hf.RequestMatcher.Scenarios.GetAll()
+	for _, pair := range hf.RequestMatcher.TemplateStore {
+		if _, ok := states[pair.Scenario]; pair.Scenario != "" && !ok {
+			states[pair.Scenario] = matching.ScenarioStarted
+		}
+	}
+
+	scenarios := []v2.ScenarioView{}
+	for name, state := range states {
+		scenarios = append(scenarios, v2.ScenarioView{Name: name, State: state})
+	}
+	sort.Slice(scenarios, func(i, j int) bool {
+		return scenarios[i].Name < scenarios[j].Name
+	})
+
+	return v2.ScenariosView{Scenarios: scenarios}
+}
+
+// ResetScenario puts a scenario back into its started state
+func (hf *Hoverfly) ResetScenario(name string) error {
+	for _, scenario := range hf.GetScenarios().Scenarios {
+		if scenario.Name == name {
+			hf.RequestMatcher.Scenarios.SetState(name, matching.ScenarioStarted)
+			return nil
+		}
+	}
+	return fmt.Errorf("Scenario %s does not exist", name)
+}
+
+// ResetScenarios puts every scenario back into its started state
+func (hf *Hoverfly) ResetScenarios() {
+	hf.RequestMatcher.Scenarios.ResetAll()
+}
+
+// GetDiff returns the differences diff mode has found between real responses
+// and the simulation
+func (hf *Hoverfly) GetDiff() v2.DiffView {
+	diffs := []v2.ResponseDiffView{}
+	for _, diff := range hf.ResponseDiffs.GetAll() {
+		diffs = append(diffs, diff.ConvertToResponseDiffView())
+	}
+	return v2.DiffView{Diffs: diffs}
+}
+
+func (hf *Hoverfly) ClearDiff() {
+	hf.ResponseDiffs.Clear()
 }
 
 func (hf Hoverfly) GetStats() metrics.Stats {
@@ -159,7 +357,8 @@ func (hf Hoverfly) GetRecords() ([]v1.RequestResponsePairView, error) {
 
 	for _, v := range records {
 		if pair, err := models.NewRequestResponsePairFromBytes(v); err == nil {
-			pairView := pair.ConvertToV1RequestResponsePairView()
+			redacted := hf.RequestMatcher.Redaction.Apply(*pair)
+			pairView := redacted.ConvertToV1RequestResponsePairView()
 			pairViews = append(pairViews, *pairView)
 		} else {
 			log.Error(err)
@@ -186,7 +385,9 @@ func (hf Hoverfly) GetSimulation() (v2.SimulationView, error) {
 
 	for _, v := range records {
 		if pair, err := models.NewRequestResponsePairFromBytes(v); err == nil {
-			pairView := pair.ConvertToRequestResponsePairView()
+			// recorded before the redaction was configured, or imported
+			redacted := hf.RequestMatcher.Redaction.Apply(*pair)
+			pairView := redacted.ConvertToRequestResponsePairView()
 			pairViews = append(pairViews, pairView)
 		} else {
 			log.Error(err)
@@ -209,7 +410,9 @@ func (hf Hoverfly) GetSimulation() (v2.SimulationView, error) {
 		DataView: v2.DataView{
 			RequestResponsePairs: pairViews,
 			GlobalActions: v2.GlobalActionsView{
-				Delays: responseDelays.Data,
+				Delays:    responseDelays.Data,
+				Faults:    hf.ResponseFaults.ConvertToResponseFaultViews(),
+				Throttles: hf.ResponseThrottles.ConvertToResponseThrottleViews(),
 			},
 		},
 	}, nil
@@ -231,11 +434,24 @@ func (this *Hoverfly) PutSimulation(simulationView v2.SimulationView) error {
 		return err
 	}
 
+	err = this.SetResponseFaults(simulationView.GlobalActions.Faults)
+	if err != nil {
+		return err
+	}
+
+	err = this.SetResponseThrottles(simulationView.GlobalActions.Throttles)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (this *Hoverfly) DeleteSimulation() {
 	this.DeleteTemplateCache()
 	this.DeleteResponseDelays()
+	this.DeleteResponseFaults()
+	this.DeleteResponseThrottles()
 	this.DeleteRequestCache()
+	this.RequestMatcher.Scenarios.Wipe()
 }
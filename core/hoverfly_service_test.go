@@ -1,7 +1,11 @@
 package hoverfly
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
@@ -350,3 +354,405 @@ func TestHoverfly_PutSimulation_ImportsDelays(t *testing.T) {
 	Expect(delays.Data[1].HttpMethod).To(Equal(""))
 	Expect(delays.Data[1].Delay).To(Equal(201))
 }
+
+func TestHoverfly_SetHashConfiguration_RehashesPairsAlreadyInTheCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	pair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Destination: "test.com",
+			Path:        "/testing",
+			Method:      "GET",
+			Query:       "q=1&_=1475000000",
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "test-body",
+		},
+	}
+
+	err := unit.RequestMatcher.SaveRequestResponsePair(&pair)
+	Expect(err).To(BeNil())
+
+	err = unit.SetHashConfiguration(v2.HashConfigurationView{
+		ExcludeQueryParams: []string{"_"},
+	})
+	Expect(err).To(BeNil())
+
+	response, matchingErr := unit.RequestMatcher.GetResponse(&models.RequestDetails{
+		Destination: "test.com",
+		Path:        "/testing",
+		Method:      "GET",
+		Query:       "q=1&_=1476000000",
+	})
+	Expect(matchingErr).To(BeNil())
+	Expect(response.Body).To(Equal("test-body"))
+
+	count, err := unit.RequestCache.RecordsCount()
+	Expect(err).To(BeNil())
+	Expect(count).To(Equal(1))
+}
+
+func TestHoverfly_SetHashConfiguration_ReturnsErrorForInvalidBodyPath(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.SetHashConfiguration(v2.HashConfigurationView{
+		ExcludeBodyPaths: []string{"$.items[a]"},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(unit.RequestMatcher.HashConfiguration).To(BeNil())
+}
+
+func TestHoverfly_SetHashConfiguration_RejectsAConfigurationWhichMergesPairs(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	for i, query := range []string{"_=1", "_=2"} {
+		pair := models.RequestResponsePair{
+			Request: models.RequestDetails{
+				Destination: "test.com",
+				Path:        "/testing",
+				Method:      "GET",
+				Query:       query,
+			},
+			Response: models.ResponseDetails{
+				Status: 200,
+				Body:   fmt.Sprintf("body-%d", i),
+			},
+		}
+		Expect(unit.RequestMatcher.SaveRequestResponsePair(&pair)).To(BeNil())
+	}
+
+	err := unit.SetHashConfiguration(v2.HashConfigurationView{
+		ExcludeQueryParams: []string{"_"},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(unit.RequestMatcher.HashConfiguration).To(BeNil())
+
+	count, _ := unit.RequestCache.RecordsCount()
+	Expect(count).To(Equal(2))
+}
+
+func TestHoverfly_SetHashConfiguration_IsKeptAfterARestart(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.SetHashConfiguration(v2.HashConfigurationView{
+		IncludeHeaders: []string{"X-Tenant"},
+	})
+	Expect(err).To(BeNil())
+
+	restarted := GetNewHoverfly(unit.Cfg, unit.RequestCache, unit.MetadataCache, nil)
+
+	Expect(restarted.GetHashConfiguration().IncludeHeaders).To(ConsistOf("X-Tenant"))
+}
+
+func TestHoverfly_GetHashConfiguration_ReturnsTheHashConfiguration(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.GetHashConfiguration()).To(Equal(v2.HashConfigurationView{}))
+
+	unit.SetHashConfiguration(v2.HashConfigurationView{
+		IncludeHeaders: []string{"X-Tenant"},
+	})
+
+	Expect(unit.GetHashConfiguration().IncludeHeaders).To(ConsistOf("X-Tenant"))
+}
+
+func TestHoverfly_GetScenarios_IncludesScenariosOfTemplates(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.RequestMatcher.TemplateStore.AddRequestTemplateResponsePair(matching.RequestTemplateResponsePair{
+		RequestTemplate: matching.RequestTemplate{Path: util.StringToPointer("/order")},
+		Scenario:        "order",
+	})
+	unit.RequestMatcher.Scenarios.SetState("payment", "failed")
+
+	Expect(unit.GetScenarios().Scenarios).To(Equal([]v2.ScenarioView{
+		{Name: "order", State: matching.ScenarioStarted},
+		{Name: "payment", State: "failed"},
+	}))
+}
+
+func TestHoverfly_ResetScenario_ResetsAKnownScenario(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.RequestMatcher.Scenarios.SetState("payment", "failed")
+
+	Expect(unit.ResetScenario("payment")).To(BeNil())
+	Expect(unit.RequestMatcher.Scenarios.GetState("payment")).To(Equal(matching.ScenarioStarted))
+
+	Expect(unit.ResetScenario("unknown")).ToNot(BeNil())
+}
+
+func TestHoverfly_PutSimulation_ImportsScenarios(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	request := v2.RequestDetailsView{
+		Destination: util.StringToPointer("test.com"),
+		Path:        util.StringToPointer("/order/1"),
+		Method:      util.StringToPointer("GET"),
+		Scheme:      util.StringToPointer("http"),
+		Query:       util.StringToPointer(""),
+		Body:        util.StringToPointer(""),
+	}
+
+	err := unit.PutSimulation(v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request:               request,
+					Response:              v2.ResponseDetailsView{Status: 200, Body: "PENDING"},
+					Scenario:              "order",
+					RequiredScenarioState: matching.ScenarioStarted,
+					NewScenarioState:      "shipped",
+				},
+				{
+					Request:               request,
+					Response:              v2.ResponseDetailsView{Status: 200, Body: "SHIPPED"},
+					Scenario:              "order",
+					RequiredScenarioState: "shipped",
+				},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	requestDetails := models.RequestDetails{Destination: "test.com", Path: "/order/1", Method: "GET", Scheme: "http"}
+
+	response, matchingErr := unit.RequestMatcher.GetResponse(&requestDetails)
+	Expect(matchingErr).To(BeNil())
+	Expect(response.Body).To(Equal("PENDING"))
+
+	response, matchingErr = unit.RequestMatcher.GetResponse(&requestDetails)
+	Expect(matchingErr).To(BeNil())
+	Expect(response.Body).To(Equal("SHIPPED"))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.DataView.RequestResponsePairs).To(HaveLen(2))
+	Expect(simulation.DataView.RequestResponsePairs[0].Scenario).To(Equal("order"))
+}
+
+func TestHoverfly_PutSimulation_RendersTemplatedResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.PutSimulation(v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request: v2.RequestDetailsView{
+						RequestType: util.StringToPointer("template"),
+						Path:        util.StringToPointer("/orders/*"),
+					},
+					Response: v2.ResponseDetailsView{
+						Status:    200,
+						Body:      `{"id": "{{ pathSegment 1 }}"}`,
+						Templated: true,
+					},
+				},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	r, _ := http.NewRequest("GET", "http://test.com/orders/42", nil)
+	unit.Cfg.SetMode("simulate")
+
+	response := unit.processRequest(r)
+	body, _ := ioutil.ReadAll(response.Body)
+
+	Expect(response.StatusCode).To(Equal(200))
+	Expect(string(body)).To(Equal(`{"id": "42"}`))
+}
+
+func TestHoverfly_PutSimulation_RejectsInvalidResponseTemplates(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.PutSimulation(v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request: v2.RequestDetailsView{
+						RequestType: util.StringToPointer("template"),
+						Path:        util.StringToPointer("/orders/*"),
+					},
+					Response: v2.ResponseDetailsView{
+						Status:    200,
+						Body:      `{{ pathSegment`,
+						Templated: true,
+					},
+				},
+			},
+		},
+	})
+	Expect(err).ToNot(BeNil())
+}
+
+func TestHoverfly_SetResponseDelays_KeepsDistributions(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	delay := v1.ResponseDelayView{
+		UrlPattern:   ".",
+		Distribution: "uniform",
+		Min:          100,
+		Max:          200,
+	}
+
+	err := unit.SetResponseDelays(v1.ResponseDelayPayloadView{Data: []v1.ResponseDelayView{delay}})
+	Expect(err).To(BeNil())
+
+	Expect(unit.GetResponseDelays().Data).To(Equal([]v1.ResponseDelayView{delay}))
+}
+
+func TestHoverfly_SetResponseDelays_MatchesDelaysOnTheRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.SetResponseDelays(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{
+				Delay: 500,
+				Request: &v1.RequestDetailsView{
+					Headers: map[string][]string{"X-Tenant": []string{"big"}},
+				},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	big := models.RequestDetails{Destination: "test.com", Headers: map[string][]string{"X-Tenant": []string{"big"}}}
+	small := models.RequestDetails{Destination: "test.com", Headers: map[string][]string{"X-Tenant": []string{"small"}}}
+
+	Expect(unit.ResponseDelays.GetDelay(big).Delay).To(Equal(500))
+	Expect(unit.ResponseDelays.GetDelay(small)).To(BeNil())
+}
+
+func TestHoverfly_SetResponseDelays_RejectsInvalidRequestMatchers(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.SetResponseDelays(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{
+				Delay:   500,
+				Request: &v1.RequestDetailsView{Path: util.StringToPointer("regex:[")},
+			},
+		},
+	})
+	Expect(err).ToNot(BeNil())
+}
+
+func TestHoverfly_PutSimulation_AnAttachedDelayTakesThePlaceOfGlobalDelays(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	simulation := v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request: v2.RequestDetailsView{
+						RequestType: util.StringToPointer("template"),
+						Path:        util.StringToPointer("/slow"),
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   "slow",
+						Delay:  &v1.DelayView{Delay: 10},
+					},
+				},
+			},
+			GlobalActions: v2.GlobalActionsView{
+				Delays: []v1.ResponseDelayView{
+					{UrlPattern: "test.com", Delay: 2000},
+				},
+			},
+		},
+	}
+	Expect(unit.PutSimulation(simulation)).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	r, _ := http.NewRequest("GET", "http://test.com/slow", nil)
+
+	start := time.Now()
+	response := unit.processRequest(r)
+
+	Expect(response.StatusCode).To(Equal(200))
+	Expect(time.Since(start)).To(BeNumerically("<", 2000*time.Millisecond))
+}
+
+func TestHoverfly_PutSimulation_DelaysResponsesWithAnAttachedDelay(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	simulation := v2.SimulationView{
+		DataView: v2.DataView{
+			RequestResponsePairs: []v2.RequestResponsePairView{
+				{
+					Request: v2.RequestDetailsView{
+						RequestType: util.StringToPointer("template"),
+						Path:        util.StringToPointer("/slow"),
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   "slow",
+						Delay:  &v1.DelayView{Delay: 100},
+					},
+				},
+			},
+		},
+	}
+	Expect(unit.PutSimulation(simulation)).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	r, _ := http.NewRequest("GET", "http://test.com/slow", nil)
+
+	start := time.Now()
+	response := unit.processRequest(r)
+
+	Expect(response.StatusCode).To(Equal(200))
+	Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+
+	exported, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(exported.RequestResponsePairs[0].Response.Delay).To(Equal(&v1.DelayView{Delay: 100}))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/authentication/backends"
 	"github.com/SpectoLabs/hoverfly/core/cache"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	. "github.com/onsi/gomega"
 	"io/ioutil"
@@ -70,6 +71,29 @@ func TestProcessCaptureRequest(t *testing.T) {
 	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
 }
 
+func TestProcessCaptureRequestWithCaptureSequenceRecordsEveryResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer dbClient.RequestCache.DeleteData()
+
+	dbClient.Cfg.CaptureSequence = CaptureSequenceStick
+	dbClient.Cfg.SetMode("capture")
+
+	for i := 0; i < 3; i++ {
+		r, err := http.NewRequest("GET", "http://somehost.com", nil)
+		Expect(err).To(BeNil())
+
+		resp := dbClient.processRequest(r)
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	}
+
+	count, err := dbClient.RequestCache.RecordsCount()
+	Expect(err).To(BeNil())
+	Expect(count).To(Equal(3))
+}
+
 func TestProcessSimulateRequest(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -343,3 +367,117 @@ func TestDelayNotAppliedToFailedModifyRequest(t *testing.T) {
 
 	Expect(stub.gotDelays).To(Equal(0))
 }
+
+func TestProcessSpyRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer dbClient.RequestCache.DeleteData()
+
+	stubbed := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Method:      "GET",
+			Scheme:      "http",
+			Destination: "somehost.com",
+			Path:        "/stubbed",
+		},
+		Response: models.ResponseDetails{Status: 200, Body: "stubbed"},
+	}
+	Expect(dbClient.RequestMatcher.SaveRequestResponsePair(&stubbed)).To(BeNil())
+
+	dbClient.Cfg.SetMode(SpyMode)
+
+	r, err := http.NewRequest("GET", "http://somehost.com/stubbed", nil)
+	Expect(err).To(BeNil())
+	resp := dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	body, _ := ioutil.ReadAll(resp.Body)
+	Expect(string(body)).To(Equal("stubbed"))
+
+	r, err = http.NewRequest("GET", "http://somehost.com/other", nil)
+	Expect(err).To(BeNil())
+	resp = dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+	simulation, err := dbClient.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+}
+
+func TestProcessSpyRequest_CapturesForwardedRequestsWhenConfigured(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer dbClient.RequestCache.DeleteData()
+
+	dbClient.Cfg.SetMode(SpyMode)
+	dbClient.Cfg.SpyCapture = true
+
+	r, err := http.NewRequest("GET", "http://somehost.com/other", nil)
+	Expect(err).To(BeNil())
+	resp := dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+	simulation, err := dbClient.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(*simulation.RequestResponsePairs[0].Request.Path).To(Equal("/other"))
+
+	// now simulated, so served without reaching the destination
+	server.Close()
+	resp = dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+}
+
+func TestProcessSpyRequest_ForwardsMissesWithTheirHeadersAsTheyWere(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer dbClient.RequestCache.DeleteData()
+
+	dbClient.RequestMatcher.TemplateStore.AddRequestTemplateResponsePair(matching.RequestTemplateResponsePair{
+		RequestTemplate: matching.RequestTemplate{
+			Headers: map[string][]string{"X-Custom": []string{"stubbed"}},
+		},
+		Response: models.ResponseDetails{Status: 200, Body: "stubbed"},
+	})
+
+	dbClient.Cfg.SetMode(SpyMode)
+	dbClient.Cfg.SpyCapture = true
+
+	r, err := http.NewRequest("GET", "http://somehost.com/other", nil)
+	Expect(err).To(BeNil())
+	r.Header.Set("X-Custom", "forwarded")
+
+	resp := dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	Expect(r.Header.Get("X-Custom")).To(Equal("forwarded"))
+
+	simulation, err := dbClient.GetSimulation()
+	Expect(err).To(BeNil())
+	for _, pair := range simulation.RequestResponsePairs {
+		if pair.Request.Path != nil && *pair.Request.Path == "/other" {
+			Expect(pair.Request.Headers).To(HaveKey("X-Custom"))
+		}
+	}
+}
+
+func TestProcessSpyRequest_ReturnsAnErrorWhenCapturingAMissFails(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer dbClient.RequestCache.DeleteData()
+	server.Close()
+
+	dbClient.Cfg.SetMode(SpyMode)
+	dbClient.Cfg.SpyCapture = true
+
+	r, err := http.NewRequest("GET", "http://somehost.com/other", nil)
+	Expect(err).To(BeNil())
+
+	resp := dbClient.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+}
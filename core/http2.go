@@ -0,0 +1,197 @@
+package hoverfly
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/rusenask/goproxy"
+)
+
+// serverProtocols accepts HTTP/1.1 and HTTP/2 with prior knowledge (h2c) on the
+// plain listener, HTTP/2 over TLS is negotiated when a CONNECT is intercepted
+func serverProtocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return protocols
+}
+
+// upstreamTransport forwards requests which arrived over HTTP/2 over HTTP/2 as
+// well, as gRPC servers require. Its HTTP/1.1 connections can record the order
+// and casing of response headers.
+type upstreamTransport struct {
+	http1 http.RoundTripper
+	http2 http.RoundTripper
+	h2c   http.RoundTripper
+}
+
+func newUpstreamTransport(tlsVerification bool) *upstreamTransport {
+	h2c := &http.Protocols{}
+	h2c.SetUnencryptedHTTP2(true)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: tlsVerification}
+
+	return &upstreamTransport{
+		http1: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				return &headerRecordingConn{Conn: conn}, nil
+			},
+			DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+
+				config := tlsConfig.Clone()
+				config.ServerName, _, _ = net.SplitHostPort(addr)
+				tlsConn := tls.Client(conn, config)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				// headers are recorded once decrypted
+				return &headerRecordingConn{Conn: tlsConn}, nil
+			},
+		},
+		http2: &http.Transport{
+			TLSClientConfig:   tlsConfig,
+			ForceAttemptHTTP2: true,
+		},
+		h2c: &http.Transport{Protocols: h2c},
+	}
+}
+
+func (this *upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.ProtoMajor != 2 {
+		return this.http1.RoundTrip(req)
+	}
+	if req.URL.Scheme == "http" {
+		return this.h2c.RoundTrip(req)
+	}
+	return this.http2.RoundTrip(req)
+}
+
+// withH2C passes cleartext HTTP/2 requests, which never have an absolute URL, on
+// to the proxy as if they had been sent to it as plain proxy requests
+func withH2C(proxy *goproxy.ProxyHttpServer) http.Handler {
+	nonproxy := proxy.NonproxyHandler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			nonproxy.ServeHTTP(w, r)
+			return
+		}
+
+		r.URL.Scheme = "http"
+		r.URL.Host = r.Host
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// mitmConnect intercepts CONNECT requests, terminating TLS with a certificate
+// signed by the goproxy CA and offering both HTTP/2 and HTTP/1.1 through ALPN.
+// Hosts every request to which passes through are tunnelled instead.
+func mitmConnect(hoverfly *Hoverfly, proxy *goproxy.ProxyHttpServer) goproxy.FuncHttpsHandler {
+	return func(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+		if hoverfly.passesThroughHost(host) {
+			return goproxy.OkConnect, host
+		}
+		return &goproxy.ConnectAction{
+			Action: goproxy.ConnectHijack,
+			Hijack: func(req *http.Request, client net.Conn, ctx *goproxy.ProxyCtx) {
+				serveMitm(proxy, req, client, ctx)
+			},
+		}, host
+	}
+}
+
+func serveMitm(proxy *goproxy.ProxyHttpServer, connect *http.Request, client net.Conn, ctx *goproxy.ProxyCtx) {
+	tlsConfig, err := goproxy.TLSConfigFromCA(&goproxy.GoproxyCa)(connect.Host, ctx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err.Error(),
+			"destination": connect.Host,
+		}).Error("Failed to sign certificate for destination")
+		client.Close()
+		return
+	}
+	tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+
+	server := &http.Server{
+		Handler: withClientWriter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Scheme = "https"
+			r.URL.Host = connect.Host
+			r.RemoteAddr = connect.RemoteAddr
+			proxy.ServeHTTP(w, r)
+		})),
+	}
+
+	conn := &closeNotifyingConn{Conn: client}
+	listener := newConnListener(tls.Server(conn, tlsConfig))
+	conn.onClose = listener.Close
+
+	// served on its own so the CONNECT request is not held open
+	go server.Serve(listener)
+}
+
+// connListener hands out a single intercepted connection, then blocks until it is
+// closed so the server serving it stops
+type connListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+	addr   net.Addr
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	listener := &connListener{
+		conns:  make(chan net.Conn, 1),
+		closed: make(chan struct{}),
+		addr:   conn.LocalAddr(),
+	}
+	listener.conns <- conn
+	return listener
+}
+
+func (this *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-this.conns:
+		return conn, nil
+	case <-this.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (this *connListener) Close() error {
+	this.once.Do(func() { close(this.closed) })
+	return nil
+}
+
+func (this *connListener) Addr() net.Addr { return this.addr }
+
+type closeNotifyingConn struct {
+	net.Conn
+	onClose func() error
+}
+
+func (this *closeNotifyingConn) Close() error {
+	err := this.Conn.Close()
+	if this.onClose != nil {
+		this.onClose()
+	}
+	return err
+}
+
+// writeTrailer sends the trailers of a response once its body has been written
+func writeTrailer(w http.ResponseWriter, trailer http.Header) {
+	for name, values := range trailer {
+		w.Header()[http.TrailerPrefix+name] = values
+	}
+}
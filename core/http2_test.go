@@ -0,0 +1,123 @@
+package hoverfly
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// a gRPC request message, framed with its length, which is not valid UTF-8
+var grpcMessage = []byte{0, 0, 0, 0, 3, 0x0a, 0x01, 0xff}
+
+// grpcEchoHandler replies to a unary call with the request message and a grpc-status trailer
+func grpcEchoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(200)
+		w.Write(body)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	})
+}
+
+func callGrpc(client *http.Client, target string) {
+	req, _ := http.NewRequest("POST", target+"/helloworld.Greeter/SayHello", bytes.NewReader(grpcMessage))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+
+	resp, err := client.Do(req)
+	Expect(err).To(BeNil())
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	Expect(err).To(BeNil())
+	Expect(resp.ProtoMajor).To(Equal(2))
+	Expect(body).To(Equal(grpcMessage))
+	Expect(resp.Trailer.Get("Grpc-Status")).To(Equal("0"))
+}
+
+func expectGrpcCaptured(unit *Hoverfly) {
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+
+	pair := simulation.RequestResponsePairs[0]
+	Expect(*pair.Request.Path).To(Equal("/helloworld.Greeter/SayHello"))
+	Expect(pair.Request.EncodedBody).To(BeTrue())
+	Expect(pair.Response.Trailers["Grpc-Status"]).To(Equal([]string{"0"}))
+}
+
+func TestHoverfly_CapturesAndSimulatesCleartextHTTP2(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewUnstartedServer(grpcEchoHandler())
+	upstream.Config.Protocols = serverProtocols()
+	upstream.Start()
+	defer upstream.Close()
+
+	proxy := httptest.NewUnstartedServer(withClientWriter(NewProxy(unit)))
+	proxy.Config.Protocols = serverProtocols()
+	proxy.Start()
+	defer proxy.Close()
+
+	protocols := &http.Protocols{}
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{
+		Protocols: protocols,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial(network, proxy.Listener.Addr().String())
+		},
+	}}
+
+	unit.Cfg.SetMode("capture")
+	callGrpc(client, upstream.URL)
+	expectGrpcCaptured(unit)
+
+	unit.Cfg.SetMode("simulate")
+	upstream.Close()
+	callGrpc(client, upstream.URL)
+}
+
+func TestHoverfly_CapturesAndSimulatesHTTP2OverTLS(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewUnstartedServer(grpcEchoHandler())
+	upstream.EnableHTTP2 = true
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(proxy.URL)
+		},
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+
+	unit.Cfg.SetMode("capture")
+	callGrpc(client, upstream.URL)
+	expectGrpcCaptured(unit)
+
+	unit.Cfg.SetMode("simulate")
+	upstream.Close()
+	callGrpc(client, upstream.URL)
+}
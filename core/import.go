@@ -15,6 +15,7 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/interfaces"
 	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/templating"
 	. "github.com/SpectoLabs/hoverfly/core/util"
 	"io/ioutil"
 	"net/http"
@@ -143,6 +144,52 @@ func (hf *Hoverfly) ImportFromURL(url string) error {
 	return hf.ImportRequestResponsePairViews(requestResponsePairViews)
 }
 
+func validateResponse(response models.ResponseDetails) error {
+	if response.Fault != nil {
+		if err := response.Fault.Validate(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Error("Failed to import response fault")
+			return err
+		}
+	}
+
+	if response.Delay != nil {
+		if err := response.Delay.Validate(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Error("Failed to import response delay")
+			return err
+		}
+	}
+
+	if err := models.ValidateWebSocketMessages(response.WebSocketMessages); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to import WebSocket messages")
+		return err
+	}
+
+	if err := matching.ValidateWebSocketMatchers(response.WebSocketMessages); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to import WebSocket messages")
+		return err
+	}
+
+	if !response.Templated {
+		return nil
+	}
+
+	if err := templating.Validate(response); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Failed to import response template")
+		return fmt.Errorf("Response template: %s", err.Error())
+	}
+	return nil
+}
+
 func isJSON(s string) bool {
 	var js map[string]interface{}
 	return json.Unmarshal([]byte(s), &js) == nil
@@ -159,22 +206,29 @@ func (hf *Hoverfly) ImportRequestResponsePairViews(pairViews []interfaces.Reques
 			if pairView.GetRequest().GetRequestType() != nil && *pairView.GetRequest().GetRequestType() == *StringToPointer("template") {
 				responseDetails := models.NewResponseDetailsFromResponse(pairView.GetResponse())
 
-				requestTemplate := matching.RequestTemplate{
-					Path:        pairView.GetRequest().GetPath(),
-					Method:      pairView.GetRequest().GetMethod(),
-					Destination: pairView.GetRequest().GetDestination(),
-					Scheme:      pairView.GetRequest().GetScheme(),
-					Query:       pairView.GetRequest().GetQuery(),
-					Body:        pairView.GetRequest().GetBody(),
-					Headers:     pairView.GetRequest().GetHeaders(),
+				requestTemplate := matching.NewRequestTemplateFromView(pairView.GetRequest())
+
+				if err := requestTemplate.Validate(); err != nil {
+					log.WithFields(log.Fields{
+						"error": err.Error(),
+					}).Error("Failed to import request template")
+					return err
+				}
+
+				if err := validateResponse(responseDetails); err != nil {
+					return err
 				}
 
 				requestTemplateResponsePair := matching.RequestTemplateResponsePair{
-					RequestTemplate: requestTemplate,
-					Response:        responseDetails,
+					RequestTemplate:       requestTemplate,
+					Response:              responseDetails,
+					Priority:              pairView.GetPriority(),
+					Scenario:              pairView.GetScenario(),
+					RequiredScenarioState: pairView.GetRequiredScenarioState(),
+					NewScenarioState:      pairView.GetNewScenarioState(),
 				}
 
-				hf.RequestMatcher.TemplateStore = append(hf.RequestMatcher.TemplateStore, requestTemplateResponsePair)
+				hf.RequestMatcher.TemplateStore.AddRequestTemplateResponsePair(requestTemplateResponsePair)
 				success++
 				continue
 			}
@@ -182,6 +236,10 @@ func (hf *Hoverfly) ImportRequestResponsePairViews(pairViews []interfaces.Reques
 			// Convert PayloadView back to Payload for internal storage
 			pair := models.NewRequestResponsePairFromRequestResponsePairView(pairView)
 
+			if err := validateResponse(pair.Response); err != nil {
+				return err
+			}
+
 			if len(pair.Request.Headers) == 0 {
 				pair.Request.Headers = make(map[string][]string)
 			}
@@ -339,6 +339,31 @@ func TestImportImportRequestResponsePairs_CanImportARequestTemplateResponsePair(
 	Expect(*responseFromCache).To(Equal(response))
 }
 
+func TestImportImportRequestResponsePairs_ReturnsAnErrorForARequestTemplateWithAnInvalidRegex(t *testing.T) {
+	RegisterTestingT(t)
+
+	cache := cache.NewInMemoryCache()
+	cfg := Configuration{Webserver: false}
+	requestMatcher := matching.RequestMatcher{RequestCache: cache, Webserver: &cfg.Webserver}
+	hv := Hoverfly{RequestCache: cache, Cfg: &cfg, RequestMatcher: requestMatcher}
+
+	templatePair := v1.RequestResponsePairView{
+		Response: v1.ResponseDetailsView{
+			Status: 200,
+			Body:   "hello_world",
+		},
+		Request: v1.RequestDetailsView{
+			RequestType: StringToPointer("template"),
+			Path:        StringToPointer("regex:/users/[0-9+"),
+		},
+	}
+
+	err := hv.ImportRequestResponsePairViews([]interfaces.RequestResponsePair{templatePair})
+
+	Expect(err).ToNot(BeNil())
+	Expect(len(hv.RequestMatcher.TemplateStore)).To(Equal(0))
+}
+
 func TestImportImportRequestResponsePairs_CanImportARequestResponsePair_AndRequestTemplateResponsePair(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -3,6 +3,10 @@ package interfaces
 type RequestResponsePair interface {
 	GetRequest() Request
 	GetResponse() Response
+	GetPriority() int
+	GetScenario() string
+	GetRequiredScenarioState() string
+	GetNewScenarioState() string
 }
 
 type Request interface {
@@ -13,7 +17,10 @@ type Request interface {
 	GetScheme() *string
 	GetQuery() *string
 	GetBody() *string
+	GetEncodedBody() bool
 	GetHeaders() map[string][]string
+	GetHeaderOrder() []string
+	GetQueryParams() map[string][]string
 }
 
 type Response interface {
@@ -21,4 +28,42 @@ type Response interface {
 	GetBody() string
 	GetEncodedBody() bool
 	GetHeaders() map[string][]string
+	GetHeaderOrder() []string
+	GetTrailers() map[string][]string
+	GetContentEncoding() string
+	GetTemplated() bool
+	GetFault() Fault
+	GetDelay() Delay
+	GetEvents() []ResponseEvent
+	GetWebSocketMessages() []WebSocketMessage
+}
+
+type ResponseEvent interface {
+	GetTime() int
+	GetData() string
+}
+
+type WebSocketMessage interface {
+	GetFrom() string
+	GetType() string
+	GetData() string
+	GetTime() int
+	GetMatcher() string
+}
+
+type Delay interface {
+	GetDelay() int
+	GetDistribution() string
+	GetMin() int
+	GetMax() int
+	GetMean() int
+	GetStdDev() int
+	GetMedian() int
+	GetP99() int
+}
+
+type Fault interface {
+	GetType() string
+	GetPercentage() *int
+	GetStatus() int
 }
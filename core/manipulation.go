@@ -83,6 +83,15 @@ func (c *Constructor) ReconstructResponse() *http.Response {
 	response.Body = ioutil.NopCloser(buf)
 	response.StatusCode = c.requestResponsePair.Response.Status
 
+	if len(c.requestResponsePair.Response.Trailers) > 0 {
+		response.Trailer = make(http.Header)
+		for k, values := range c.requestResponsePair.Response.Trailers {
+			for _, v := range values {
+				response.Trailer.Add(k, v)
+			}
+		}
+	}
+
 	return response
 }
 
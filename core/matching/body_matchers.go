@@ -0,0 +1,140 @@
+package matching
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/antchfx/xpath"
+	"github.com/oliveagle/jsonpath"
+)
+
+// jsonPathExpectationSeparator splits a JSONPath expression from the value its
+// result is compared with, e.g. "jsonpath:$.order.status = SHIPPED". Without an
+// expected value, a JSONPath matcher matches when the expression finds anything.
+const jsonPathExpectationSeparator = " = "
+
+// bodyMatch checks the request body against an optional request template body.
+// JSONPath and XPath matchers will never match a body whose content type says
+// it is the other format.
+func bodyMatch(templateBody *string, req models.RequestDetails) bool {
+	if templateBody == nil {
+		return true
+	}
+
+	matcher := NewFieldMatcher(*templateBody)
+
+	contentType := req.GetContentType()
+	if matcher.Type == JSONPathMatch && contentType == models.ContentTypeXML {
+		return false
+	}
+	if matcher.Type == XPathMatch && contentType == models.ContentTypeJSON {
+		return false
+	}
+
+	return matcher.Match(req.Body)
+}
+
+// splitJSONPathExpression separates a JSONPath matcher into the expression and the
+// expected value. The separator is ignored inside brackets so that filter
+// expressions such as $.items[?(@.id = 1)] are left alone.
+func splitJSONPathExpression(value string) (expression string, expected *string) {
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		}
+
+		if depth == 0 && strings.HasPrefix(value[i:], jsonPathExpectationSeparator) {
+			expectedValue := value[i+len(jsonPathExpectationSeparator):]
+			return value[:i], &expectedValue
+		}
+	}
+	return value, nil
+}
+
+func jsonPathMatch(value, body string) bool {
+	expression, expected := splitJSONPathExpression(value)
+
+	compiled, err := jsonpath.Compile(expression)
+	if err != nil {
+		return false
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return false
+	}
+
+	result, err := compiled.Lookup(data)
+	if err != nil {
+		return false
+	}
+
+	if expected == nil {
+		if results, ok := result.([]interface{}); ok {
+			return len(results) > 0
+		}
+		return result != nil
+	}
+
+	return fieldMatch(expected, jsonPathResultToString(result))
+}
+
+func jsonPathResultToString(result interface{}) string {
+	if value, ok := result.(string); ok {
+		return value
+	}
+
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprint(result)
+	}
+	return string(bytes)
+}
+
+// xPathMatch evaluates an XPath expression against the body. Boolean expressions
+// such as "/order/status = 'SHIPPED'" match when true, anything else matches
+// when it selects at least one node or a non-empty value.
+func xPathMatch(value, body string) bool {
+	expression, err := xpath.Compile(value)
+	if err != nil {
+		return false
+	}
+
+	document, err := parseXML(body)
+	if err != nil {
+		return false
+	}
+
+	switch result := expression.Evaluate(newXMLNavigator(document)).(type) {
+	case bool:
+		return result
+	case float64:
+		return result != 0
+	case string:
+		return result != ""
+	case *xpath.NodeIterator:
+		return result.MoveNext()
+	}
+	return false
+}
+
+func validateJSONPath(value string) error {
+	expression, _ := splitJSONPathExpression(value)
+	if _, err := jsonpath.Compile(expression); err != nil {
+		return fmt.Errorf("%s is not a valid JSONPath expression", expression)
+	}
+	return nil
+}
+
+func validateXPath(value string) error {
+	if _, err := xpath.Compile(value); err != nil {
+		return fmt.Errorf("%s is not a valid XPath expression", value)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+const orderJSON = `{"order": {"id": 42, "status": "PENDING", "items": [{"sku": "a"}, {"sku": "b"}]}}`
+
+const orderXML = `<?xml version="1.0"?>
+<order id="42">
+	<status>PENDING</status>
+	<items>
+		<item sku="a"/>
+		<item sku="b"/>
+	</items>
+</order>`
+
+func TestJSONPathMatcher_MatchesWhenExpressionFindsAValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("jsonpath:$.order.status").Match(orderJSON)).To(BeTrue())
+	Expect(NewFieldMatcher("jsonpath:$.order.customer").Match(orderJSON)).To(BeFalse())
+}
+
+func TestJSONPathMatcher_ComparesResultWithExpectedValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("jsonpath:$.order.status = PENDING").Match(orderJSON)).To(BeTrue())
+	Expect(NewFieldMatcher("jsonpath:$.order.status = SHIPPED").Match(orderJSON)).To(BeFalse())
+	Expect(NewFieldMatcher("jsonpath:$.order.id = 42").Match(orderJSON)).To(BeTrue())
+	Expect(NewFieldMatcher("jsonpath:$.order.items[1].sku = b").Match(orderJSON)).To(BeTrue())
+}
+
+func TestJSONPathMatcher_ExpectedValueCanUseAnotherMatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("jsonpath:$.order.status = PEND*").Match(orderJSON)).To(BeTrue())
+	Expect(NewFieldMatcher("jsonpath:$.order.id = regex:^[0-9]+$").Match(orderJSON)).To(BeTrue())
+}
+
+func TestJSONPathMatcher_IgnoresKeyOrderAndExtraFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := `{"extra": true, "order": {"status": "PENDING", "id": 42}}`
+
+	Expect(NewFieldMatcher("jsonpath:$.order.id = 42").Match(body)).To(BeTrue())
+}
+
+func TestJSONPathMatcher_DoesNotMatchInvalidJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("jsonpath:$.order").Match(orderXML)).To(BeFalse())
+}
+
+func TestXPathMatcher_MatchesWhenExpressionSelectsANode(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("xpath:/order/status").Match(orderXML)).To(BeTrue())
+	Expect(NewFieldMatcher("xpath:/order/customer").Match(orderXML)).To(BeFalse())
+	Expect(NewFieldMatcher("xpath://item[@sku='b']").Match(orderXML)).To(BeTrue())
+}
+
+func TestXPathMatcher_MatchesBooleanExpressions(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("xpath:/order/status = 'PENDING'").Match(orderXML)).To(BeTrue())
+	Expect(NewFieldMatcher("xpath:/order/status = 'SHIPPED'").Match(orderXML)).To(BeFalse())
+	Expect(NewFieldMatcher("xpath:/order/@id = '42'").Match(orderXML)).To(BeTrue())
+	Expect(NewFieldMatcher("xpath:count(//item) = 2").Match(orderXML)).To(BeTrue())
+}
+
+func TestXPathMatcher_DoesNotMatchInvalidXML(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("xpath:/order").Match(orderJSON)).To(BeFalse())
+}
+
+func TestBodyMatchers_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("jsonpath:$.order.id = 42").Validate()).To(BeNil())
+	Expect(NewFieldMatcher("jsonpath:order[").Validate()).ToNot(BeNil())
+	Expect(NewFieldMatcher("xpath:/order/status").Validate()).To(BeNil())
+	Expect(NewFieldMatcher("xpath:/order[").Validate()).ToNot(BeNil())
+}
+
+func TestTemplatesCanUseJSONPathOnBodyAndBeMatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				Body: StringToPointer("jsonpath:$.order.id = 42"),
+			},
+			Response: models.ResponseDetails{
+				Body: "body",
+			},
+		},
+	}
+
+	result, err := store.GetResponse(models.RequestDetails{
+		Body:    orderJSON,
+		Headers: map[string][]string{"Content-Type": []string{"application/json"}},
+	}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+}
+
+func TestTemplatesWithXPathBodyDoNotMatchJSONRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				Body: StringToPointer("xpath:/order"),
+			},
+			Response: models.ResponseDetails{
+				Body: "body",
+			},
+		},
+	}
+
+	result, err := store.GetResponse(models.RequestDetails{
+		Body:    orderXML,
+		Headers: map[string][]string{"Content-Type": []string{"application/json"}},
+	}, false)
+	Expect(err).ToNot(BeNil())
+	Expect(result).To(BeNil())
+
+	result, err = store.GetResponse(models.RequestDetails{
+		Body:    orderXML,
+		Headers: map[string][]string{"Content-Type": []string{"application/xml"}},
+	}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+}
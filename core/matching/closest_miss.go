@@ -0,0 +1,147 @@
+package matching
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// ClosestMiss is the recorded request or request template which came closest to
+// matching a request that Hoverfly could not find a response for
+type ClosestMiss struct {
+	// RequestDetails - set when the closest miss is a recorded request
+	RequestDetails *models.RequestDetails
+	// RequestTemplate - set when the closest miss is a request template
+	RequestTemplate *RequestTemplate
+	Response        models.ResponseDetails
+	// MissedFields - the fields which did not match, e.g. "path" or "header Authorization"
+	MissedFields []string
+
+	matchedFields int
+}
+
+// closerThan prefers the candidate with the fewest missed fields, and then the
+// candidate which matched the most fields
+func (this *ClosestMiss) closerThan(other *ClosestMiss) bool {
+	if other == nil {
+		return true
+	}
+	if len(this.MissedFields) != len(other.MissedFields) {
+		return len(this.MissedFields) < len(other.MissedFields)
+	}
+	return this.matchedFields > other.matchedFields
+}
+
+func (this ClosestMiss) String() string {
+	var buffer bytes.Buffer
+
+	var request interface{}
+	if this.RequestTemplate != nil {
+		buffer.WriteString("The closest miss was the request template:\n")
+		request = this.RequestTemplate
+	} else {
+		buffer.WriteString("The closest miss was the recorded request:\n")
+		request = this.RequestDetails
+	}
+
+	requestJSON, _ := json.MarshalIndent(request, "", "    ")
+	buffer.Write(requestJSON)
+
+	buffer.WriteString("\nwhich did not match on: " + strings.Join(this.MissedFields, ", "))
+
+	return buffer.String()
+}
+
+// closestMiss scores every recorded request and request template against the request
+func (this *RequestMatcher) closestMiss(req models.RequestDetails) *ClosestMiss {
+	closest := this.TemplateStore.closestMiss(req, *this.Webserver, this.Scenarios)
+
+	values, err := this.RequestCache.GetAllValues()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Warn("Failed to read recorded requests when looking for the closest miss")
+		return closest
+	}
+
+	// recorded requests are stored redacted, so are compared with the request once redacted
+	redacted := this.Redaction.RedactRequest(req)
+
+	for _, value := range values {
+		pair, err := models.NewRequestResponsePairFromBytes(value)
+		if err != nil {
+			continue
+		}
+
+		missed, matched := this.recordingMissedFields(pair.Request, redacted)
+		if !this.Scenarios.inRequiredState(pair.Scenario, pair.RequiredScenarioState) {
+			missed = append(missed, "scenario state")
+		}
+
+		candidate := &ClosestMiss{
+			RequestDetails: &pair.Request,
+			Response:       pair.Response,
+			MissedFields:   missed,
+			matchedFields:  matched,
+		}
+		if candidate.closerThan(closest) {
+			closest = candidate
+		}
+	}
+
+	if closest != nil {
+		log.WithFields(log.Fields{
+			"query":        req.Query,
+			"path":         req.Path,
+			"destination":  req.Destination,
+			"method":       req.Method,
+			"missedFields": closest.MissedFields,
+		}).Warn("Found closest miss for request")
+	}
+
+	return closest
+}
+
+// recordingMissedFields compares the parts of a recorded request and a request
+// which make up their keys
+func (this *RequestMatcher) recordingMissedFields(recorded, req models.RequestDetails) (missed []string, matched int) {
+	recorded = this.HashConfiguration.Apply(recorded)
+	req = this.HashConfiguration.Apply(req)
+
+	check := func(field string, ok bool) {
+		if ok {
+			matched++
+		} else {
+			missed = append(missed, field)
+		}
+	}
+
+	if !*this.Webserver {
+		check("destination", recorded.Destination == req.Destination)
+	}
+	check("method", recorded.Method == req.Method)
+	check("path", recorded.Path == req.Path)
+	check("query", models.SortQuery(recorded.Query) == models.SortQuery(req.Query))
+
+	if this.HashConfiguration != nil {
+		for _, name := range this.HashConfiguration.IncludeHeaders {
+			check("header "+name, strings.Join(headerValues(recorded.Headers, name), ",") == strings.Join(headerValues(req.Headers, name), ","))
+		}
+	}
+
+	check("body", recorded.CanonicalBody() == req.CanonicalBody())
+
+	return missed, matched
+}
+
+func headerValues(headers map[string][]string, name string) []string {
+	for headerName, values := range headers {
+		if strings.EqualFold(headerName, name) {
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/cache"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func newTestRequestMatcher() RequestMatcher {
+	webserver := false
+	return RequestMatcher{
+		RequestCache:  cache.NewInMemoryCache(),
+		TemplateStore: RequestTemplateStore{},
+		Webserver:     &webserver,
+	}
+}
+
+func TestRequestMatcher_GetResponse_ReturnsTheClosestRecordedRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:  models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/users", Query: "page=1"},
+		Response: models.ResponseDetails{Status: 200, Body: "close"},
+	})
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:  models.RequestDetails{Destination: "other.com", Method: "POST", Path: "/orders"},
+		Response: models.ResponseDetails{Status: 200, Body: "far"},
+	})
+
+	_, err := unit.GetResponse(&models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/users", Query: "page=2"})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.StatusCode).To(Equal(412))
+	Expect(err.ClosestMiss()).ToNot(BeNil())
+	Expect(err.ClosestMiss().Response.Body).To(Equal("close"))
+	Expect(err.ClosestMiss().MissedFields).To(Equal([]string{"query"}))
+	Expect(err.Error()).To(ContainSubstring("which did not match on: query"))
+}
+
+func TestRequestMatcher_GetResponse_OnlyFindsTheClosestMissWhenItIsAskedFor(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:  models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/users"},
+		Response: models.ResponseDetails{Status: 200, Body: "close"},
+	})
+
+	_, err := unit.GetResponse(&models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/orders"})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.searched).To(BeFalse())
+
+	Expect(err.ClosestMiss()).ToNot(BeNil())
+	Expect(err.searched).To(BeTrue())
+}
+
+func TestRequestMatcher_GetResponse_ComparesTheRedactedRequestWithRecordedRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Redaction = &models.Redaction{Mask: "REDACTED", MaskBodyPaths: []string{"$.password"}}
+
+	headers := map[string][]string{"Content-Type": []string{"application/json"}}
+
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:  models.RequestDetails{Destination: "test.com", Method: "POST", Path: "/login", Headers: headers, Body: `{"password": "secret"}`},
+		Response: models.ResponseDetails{Status: 200, Body: "close"},
+	})
+
+	_, err := unit.GetResponse(&models.RequestDetails{Destination: "test.com", Method: "POST", Path: "/signin", Headers: headers, Body: `{"password": "secret"}`})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.ClosestMiss()).ToNot(BeNil())
+	Expect(err.ClosestMiss().MissedFields).To(Equal([]string{"path"}))
+}
+
+func TestRequestMatcher_GetResponse_ReturnsTheClosestRequestTemplate(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.TemplateStore = RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				Method:  StringToPointer("GET"),
+				Path:    StringToPointer("/users/*"),
+				Headers: map[string][]string{"Authorization": []string{"Bearer *"}},
+			},
+			Response: models.ResponseDetails{Body: "template"},
+		},
+	}
+
+	_, err := unit.GetResponse(&models.RequestDetails{
+		Destination: "test.com",
+		Method:      "GET",
+		Path:        "/users/1",
+		Headers:     map[string][]string{"Authorization": []string{"Basic abc"}},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.ClosestMiss().RequestTemplate).ToNot(BeNil())
+	Expect(err.ClosestMiss().MissedFields).To(Equal([]string{"header Authorization"}))
+}
+
+func TestRequestMatcher_GetResponse_HasNoClosestMissWhenThereIsNothingToMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+
+	_, err := unit.GetResponse(&models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/"})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.ClosestMiss()).To(BeNil())
+	Expect(err.Error()).To(Equal("Could not find recorded request, please record it first!"))
+}
+
+func TestClosestMiss_closerThan_PrefersTheCandidateWhichMatchedMoreFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	specific := &ClosestMiss{MissedFields: []string{"body"}, matchedFields: 4}
+	general := &ClosestMiss{MissedFields: []string{"body"}, matchedFields: 1}
+	far := &ClosestMiss{MissedFields: []string{"path", "body"}, matchedFields: 5}
+
+	Expect(specific.closerThan(general)).To(BeTrue())
+	Expect(general.closerThan(far)).To(BeTrue())
+	Expect(far.closerThan(nil)).To(BeTrue())
+}
@@ -0,0 +1,78 @@
+package matching
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// ResponseDelay is a global response delay which, when it has a request template,
+// only delays the requests the template matches
+type ResponseDelay struct {
+	models.ResponseDelay
+	RequestTemplate *RequestTemplate
+}
+
+// ResponseDelayList holds global response delays which can be matched on whole
+// requests, it implements models.ResponseDelays
+type ResponseDelayList struct {
+	Delays    []ResponseDelay
+	Webserver *bool
+}
+
+func NewResponseDelayListFromView(payloadView v1.ResponseDelayPayloadView, webserver *bool) (*ResponseDelayList, error) {
+	if err := models.ValidateResponseDelayPayload(payloadView); err != nil {
+		return nil, err
+	}
+
+	delays := &ResponseDelayList{Webserver: webserver}
+	for _, view := range payloadView.Data {
+		delay := ResponseDelay{ResponseDelay: models.NewResponseDelayFromView(view)}
+
+		if view.Request != nil {
+			requestTemplate := NewRequestTemplateFromView(*view.Request)
+			if err := requestTemplate.Validate(); err != nil {
+				return nil, err
+			}
+			delay.RequestTemplate = &requestTemplate
+		}
+
+		delays.Delays = append(delays.Delays, delay)
+	}
+
+	return delays, nil
+}
+
+func (this *ResponseDelayList) GetDelay(request models.RequestDetails) *models.ResponseDelay {
+	for _, delay := range this.Delays {
+		if delay.RequestTemplate != nil && !delay.RequestTemplate.Matches(request, *this.Webserver) {
+			continue
+		}
+		if delay.Matches(request) {
+			log.Info("Found response delay setting for this request host: ", delay.ResponseDelay)
+			responseDelay := delay.ResponseDelay
+			return &responseDelay
+		}
+	}
+	return nil
+}
+
+func (this *ResponseDelayList) Len() int {
+	return len(this.Delays)
+}
+
+func (this *ResponseDelayList) ConvertToResponseDelayPayloadView() v1.ResponseDelayPayloadView {
+	payloadView := v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{},
+	}
+
+	for _, delay := range this.Delays {
+		view := delay.ConvertToResponseDelayView()
+		if delay.RequestTemplate != nil {
+			view.Request = delay.RequestTemplate.ConvertToRequestDetailsView()
+		}
+		payloadView.Data = append(payloadView.Data, view)
+	}
+
+	return payloadView
+}
@@ -0,0 +1,55 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func TestResponseDelayList_GetDelay_SkipsDelaysWhoseRequestTemplateDoesNotMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	webserver := false
+	delays, err := NewResponseDelayListFromView(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{
+				UrlPattern: ".",
+				Delay:      1000,
+				Request:    &v1.RequestDetailsView{Headers: map[string][]string{"X-Tenant": []string{"*"}}},
+			},
+			{UrlPattern: ".", Delay: 10},
+		},
+	}, &webserver)
+	Expect(err).To(BeNil())
+
+	withHeader := models.RequestDetails{Destination: "test.com", Headers: map[string][]string{"X-Tenant": []string{"big"}}}
+	withoutHeader := models.RequestDetails{Destination: "test.com"}
+
+	Expect(delays.GetDelay(withHeader).Delay).To(Equal(1000))
+	Expect(delays.GetDelay(withoutHeader).Delay).To(Equal(10))
+	Expect(delays.Len()).To(Equal(2))
+}
+
+func TestResponseDelayList_ConvertToResponseDelayPayloadView_IncludesTheRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	webserver := false
+	delays, err := NewResponseDelayListFromView(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{
+				Delay:   100,
+				Request: &v1.RequestDetailsView{Path: StringToPointer("/slow")},
+			},
+		},
+	}, &webserver)
+	Expect(err).To(BeNil())
+
+	payloadView := delays.ConvertToResponseDelayPayloadView()
+
+	Expect(payloadView.Data).To(HaveLen(1))
+	Expect(payloadView.Data[0].Delay).To(Equal(100))
+	Expect(payloadView.Data[0].Request.Path).To(Equal(StringToPointer("/slow")))
+}
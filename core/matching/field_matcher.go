@@ -0,0 +1,214 @@
+package matching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/ryanuber/go-glob"
+)
+
+// Matcher types which can be used as a prefix on any request template value,
+// for example "regex:^/users/[0-9]+/orders$". Values without a recognised
+// prefix are matched as globs, which is how request templates have always behaved.
+// JSONPath and XPath matchers are intended for request bodies.
+const (
+	ExactMatch           = "exact"
+	GlobMatch            = "glob"
+	RegexMatch           = "regex"
+	CaseInsensitiveMatch = "case-insensitive"
+	JSONPathMatch        = "jsonpath"
+	XPathMatch           = "xpath"
+)
+
+var matcherTypes = []string{ExactMatch, GlobMatch, RegexMatch, CaseInsensitiveMatch, JSONPathMatch, XPathMatch}
+
+// FieldMatcher describes how a single request template value is compared
+// against the corresponding value of an incoming request
+type FieldMatcher struct {
+	Type  string
+	Value string
+
+	regex *regexp.Regexp
+}
+
+// Regular expressions are compiled once, when a template is validated or first
+// matched, rather than on every request. The cache is cleared along with the
+// templates, so it does not keep the patterns of every simulation ever loaded.
+var regexCache = struct {
+	sync.RWMutex
+	compiled map[string]*regexp.Regexp
+}{compiled: map[string]*regexp.Regexp{}}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCache.RLock()
+	regex, ok := regexCache.compiled[pattern]
+	regexCache.RUnlock()
+	if ok {
+		return regex, nil
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.Lock()
+	regexCache.compiled[pattern] = regex
+	regexCache.Unlock()
+	return regex, nil
+}
+
+// clearRegexCache forgets every compiled regular expression, matchers which
+// have already been built keep their own
+func clearRegexCache() {
+	regexCache.Lock()
+	regexCache.compiled = map[string]*regexp.Regexp{}
+	regexCache.Unlock()
+}
+
+// NewFieldMatcher parses a request template value, splitting off the matcher
+// type prefix if there is one
+func NewFieldMatcher(value string) FieldMatcher {
+	for _, matcherType := range matcherTypes {
+		if strings.HasPrefix(value, matcherType+":") {
+			matcher := FieldMatcher{
+				Type:  matcherType,
+				Value: strings.TrimPrefix(value, matcherType+":"),
+			}
+			if matcherType == RegexMatch {
+				matcher.regex, _ = compileRegex(matcher.Value)
+			}
+			return matcher
+		}
+	}
+
+	return FieldMatcher{
+		Type:  GlobMatch,
+		Value: value,
+	}
+}
+
+// NewFieldMatcherOfType builds a matcher of the given type, returning an error
+// when the type is unknown or the value cannot be used with it
+func NewFieldMatcherOfType(matcherType, value string) (FieldMatcher, error) {
+	for _, known := range matcherTypes {
+		if known == matcherType {
+			matcher := NewFieldMatcher(matcherType + ":" + value)
+			return matcher, matcher.Validate()
+		}
+	}
+	return FieldMatcher{}, fmt.Errorf("Unknown matcher type: %s", matcherType)
+}
+
+func (this FieldMatcher) Match(actual string) bool {
+	switch this.Type {
+	case ExactMatch:
+		return this.Value == actual
+	case CaseInsensitiveMatch:
+		return strings.EqualFold(this.Value, actual)
+	case RegexMatch:
+		if this.regex == nil {
+			return false
+		}
+		return this.regex.MatchString(actual)
+	case JSONPathMatch:
+		return jsonPathMatch(this.Value, actual)
+	case XPathMatch:
+		return xPathMatch(this.Value, actual)
+	default:
+		return glob.Glob(this.Value, actual)
+	}
+}
+
+func (this FieldMatcher) Validate() error {
+	switch this.Type {
+	case RegexMatch:
+		if _, err := compileRegex(this.Value); err != nil {
+			return fmt.Errorf("%s is not a valid regular expression", this.Value)
+		}
+	case JSONPathMatch:
+		return validateJSONPath(this.Value)
+	case XPathMatch:
+		return validateXPath(this.Value)
+	}
+	return nil
+}
+
+// fieldMatch checks a request value against an optional request template value,
+// a nil template value matches everything
+func fieldMatch(templateValue *string, actual string) bool {
+	if templateValue == nil {
+		return true
+	}
+	return NewFieldMatcher(*templateValue).Match(actual)
+}
+
+// schemeMatch checks the request scheme against an optional request template scheme.
+// Schemes are case insensitive, so globs are matched against the lower case scheme.
+func schemeMatch(templateScheme *string, scheme string) bool {
+	if templateScheme == nil {
+		return true
+	}
+
+	matcher := NewFieldMatcher(*templateScheme)
+	if matcher.Type == GlobMatch {
+		matcher.Value = strings.ToLower(matcher.Value)
+	}
+	return matcher.Match(strings.ToLower(scheme))
+}
+
+// ValidateWebSocketMatchers checks that each recorded WebSocket message with a
+// matcher can be matched with it
+func ValidateWebSocketMatchers(messages []models.WebSocketMessage) error {
+	for i, message := range messages {
+		if message.Matcher == "" {
+			continue
+		}
+		if _, err := NewFieldMatcherOfType(message.Matcher, message.Data); err != nil {
+			return fmt.Errorf("WebSocket message %d: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+// Validate checks that every value in the request template can be used as a matcher
+func (this RequestTemplate) Validate() error {
+	fields := map[string]*string{
+		"path":        this.Path,
+		"method":      this.Method,
+		"destination": this.Destination,
+		"scheme":      this.Scheme,
+		"query":       this.Query,
+		"body":        this.Body,
+	}
+
+	for name, value := range fields {
+		if value == nil {
+			continue
+		}
+		if err := NewFieldMatcher(*value).Validate(); err != nil {
+			return fmt.Errorf("Request template %s: %s", name, err.Error())
+		}
+	}
+
+	for name, values := range this.Headers {
+		for _, value := range values {
+			if err := NewFieldMatcher(value).Validate(); err != nil {
+				return fmt.Errorf("Request template header %s: %s", name, err.Error())
+			}
+		}
+	}
+
+	for name, values := range this.QueryParams {
+		for _, value := range values {
+			if err := NewFieldMatcher(value).Validate(); err != nil {
+				return fmt.Errorf("Request template query param %s: %s", name, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package matching
+
+import (
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestNewFieldMatcher_DefaultsToGlob(t *testing.T) {
+	RegisterTestingT(t)
+
+	matcher := NewFieldMatcher("/api/*")
+
+	Expect(matcher.Type).To(Equal(GlobMatch))
+	Expect(matcher.Value).To(Equal("/api/*"))
+}
+
+func TestNewFieldMatcher_ParsesPrefix(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("exact:/api")).To(Equal(FieldMatcher{Type: ExactMatch, Value: "/api"}))
+	Expect(NewFieldMatcher("glob:/api/*")).To(Equal(FieldMatcher{Type: GlobMatch, Value: "/api/*"}))
+	Expect(NewFieldMatcher("regex:^/api$").Type).To(Equal(RegexMatch))
+	Expect(NewFieldMatcher("regex:^/api$").Value).To(Equal("^/api$"))
+	Expect(NewFieldMatcher("case-insensitive:/API")).To(Equal(FieldMatcher{Type: CaseInsensitiveMatch, Value: "/API"}))
+}
+
+func TestNewFieldMatcher_UnknownPrefixIsPartOfGlob(t *testing.T) {
+	RegisterTestingT(t)
+
+	matcher := NewFieldMatcher("http://*")
+
+	Expect(matcher.Type).To(Equal(GlobMatch))
+	Expect(matcher.Value).To(Equal("http://*"))
+}
+
+func TestFieldMatcher_Match(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewFieldMatcher("/api/*").Match("/api/users")).To(BeTrue())
+	Expect(NewFieldMatcher("exact:/api/*").Match("/api/users")).To(BeFalse())
+	Expect(NewFieldMatcher("exact:/api/*").Match("/api/*")).To(BeTrue())
+	Expect(NewFieldMatcher("regex:^/users/[0-9]+/orders$").Match("/users/42/orders")).To(BeTrue())
+	Expect(NewFieldMatcher("regex:^/users/[0-9]+/orders$").Match("/users/42/orders/1")).To(BeFalse())
+	Expect(NewFieldMatcher("case-insensitive:/API").Match("/api")).To(BeTrue())
+	Expect(NewFieldMatcher("case-insensitive:/API").Match("/apis")).To(BeFalse())
+}
+
+func TestNewFieldMatcher_ReusesCompiledRegex(t *testing.T) {
+	RegisterTestingT(t)
+
+	first := NewFieldMatcher("regex:^/users/[0-9]+$")
+	second := NewFieldMatcher("regex:^/users/[0-9]+$")
+
+	Expect(first.regex).ToNot(BeNil())
+	Expect(second.regex).To(BeIdenticalTo(first.regex))
+}
+
+func TestRequestTemplateStore_Wipe_ClearsCompiledRegexes(t *testing.T) {
+	RegisterTestingT(t)
+
+	matcher := NewFieldMatcher("regex:^/orders/[0-9]+$")
+	Expect(regexCache.compiled).To(HaveKey("^/orders/[0-9]+$"))
+
+	store := RequestTemplateStore{}
+	store.Wipe()
+
+	Expect(regexCache.compiled).To(BeEmpty())
+	Expect(matcher.Match("/orders/1")).To(BeTrue())
+}
+
+func TestFieldMatcher_InvalidRegexDoesNotMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	matcher := NewFieldMatcher("regex:[0-9")
+
+	Expect(matcher.Match("[0-9")).To(BeFalse())
+	Expect(matcher.Validate()).ToNot(BeNil())
+}
+
+func TestRequestTemplate_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(RequestTemplate{Path: StringToPointer("regex:^/api$")}.Validate()).To(BeNil())
+	Expect(RequestTemplate{Body: StringToPointer("regex:(")}.Validate()).ToNot(BeNil())
+	Expect(RequestTemplate{
+		Headers: map[string][]string{"Accept": []string{"regex:("}},
+	}.Validate()).ToNot(BeNil())
+}
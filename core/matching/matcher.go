@@ -8,23 +8,71 @@ import (
 )
 
 type RequestMatcher struct {
-	RequestCache  cache.Cache
-	TemplateStore RequestTemplateStore
-	Webserver     *bool
+	RequestCache      cache.Cache
+	TemplateStore     RequestTemplateStore
+	Webserver         *bool
+	HashConfiguration *models.HashConfiguration
+	Scenarios         *ScenarioState
+	Redaction         *models.Redaction
+}
+
+// GetKey returns the key a request is stored under in the request cache. It is
+// worked out once the request has been redacted, so a request matches a pair
+// however its redacted values differ.
+func (this *RequestMatcher) GetKey(req models.RequestDetails) string {
+	return this.HashConfiguration.Hash(this.Redaction.RedactRequest(req), !*this.Webserver)
+}
+
+// getPairBytes looks up a request in the request cache. The current state of each
+// scenario with a recording of the request is tried first, then pairs in a scenario
+// which match in any state, and last pairs which are not in a scenario.
+func (this *RequestMatcher) getPairBytes(key string) ([]byte, string, error) {
+	for _, scenario := range this.Scenarios.ScenariosFor(key) {
+		for _, state := range []string{this.Scenarios.GetState(scenario), ""} {
+			scenarioKey := models.ScenarioKey(key, scenario, state)
+			if pairBytes, err := this.RequestCache.Get([]byte(scenarioKey)); err == nil {
+				return pairBytes, scenarioKey, nil
+			}
+		}
+	}
+
+	pairBytes, err := this.RequestCache.Get([]byte(key))
+	return pairBytes, key, err
 }
 
 // getResponse returns stored response from cache
 func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.ResponseDetails, *MatchingError) {
+	this.Scenarios.lockMatching()
+	defer this.Scenarios.unlockMatching()
 
-	var key string
-
-	if *this.Webserver {
-		key = req.HashWithoutHost()
-	} else {
-		key = req.Hash()
+	response, transition, err := this.findResponse(req)
+	if err != nil {
+		return nil, err
 	}
 
-	pairBytes, err := this.RequestCache.Get([]byte(key))
+	this.transitionScenario(transition.Scenario, transition.NewScenarioState)
+
+	return response, nil
+}
+
+// PeekResponse returns the response GetResponse would, leaving the state of every
+// scenario and sequence as it is
+func (this *RequestMatcher) PeekResponse(req *models.RequestDetails) (*models.ResponseDetails, *MatchingError) {
+	response, _, err := this.findResponse(req)
+	return response, err
+}
+
+// scenarioTransition is the state a scenario moves to once a pair has matched
+type scenarioTransition struct {
+	Scenario         string
+	NewScenarioState string
+}
+
+func (this *RequestMatcher) findResponse(req *models.RequestDetails) (*models.ResponseDetails, scenarioTransition, *MatchingError) {
+
+	key := this.GetKey(*req)
+
+	pairBytes, key, err := this.getPairBytes(key)
 
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -36,7 +84,7 @@ func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.Res
 			"method":      req.Method,
 		}).Warn("Failed to retrieve response from cache")
 
-		response, err := this.TemplateStore.GetResponse(*req, *this.Webserver)
+		templatePair, err := this.TemplateStore.getPair(*req, *this.Webserver, this.Scenarios)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"key":         key,
@@ -47,9 +95,13 @@ func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.Res
 				"method":      req.Method,
 			}).Warn("Failed to find matching request template from template store")
 
-			return nil, &MatchingError{
+			missedReq := *req
+			return nil, scenarioTransition{}, &MatchingError{
 				StatusCode:  412,
 				Description: "Could not find recorded request, please record it first!",
+				findClosestMiss: func() *ClosestMiss {
+					return this.closestMiss(missedReq)
+				},
 			}
 		}
 		log.WithFields(log.Fields{
@@ -59,7 +111,8 @@ func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.Res
 			"destination": req.Destination,
 			"method":      req.Method,
 		}).Info("Found template matching request from template store")
-		return response, nil
+
+		return &templatePair.Response, scenarioTransition{templatePair.Scenario, templatePair.NewScenarioState}, nil
 	}
 
 	// getting cache response
@@ -70,7 +123,7 @@ func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.Res
 			"value": string(pairBytes),
 			"key":   key,
 		}).Error("Failed to decode payload")
-		return nil, &MatchingError{
+		return nil, scenarioTransition{}, &MatchingError{
 			StatusCode:  500,
 			Description: "Failed to decode payload",
 		}
@@ -85,17 +138,30 @@ func (this *RequestMatcher) GetResponse(req *models.RequestDetails) (*models.Res
 		"status":      pair.Response.Status,
 	}).Info("Payload found from cache")
 
-	return &pair.Response, nil
+	return &pair.Response, scenarioTransition{pair.Scenario, pair.NewScenarioState}, nil
+}
+
+func (this *RequestMatcher) transitionScenario(scenario, newState string) {
+	if scenario == "" || newState == "" {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"scenario": scenario,
+		"from":     this.Scenarios.GetState(scenario),
+		"to":       newState,
+	}).Info("Scenario state changed")
+
+	this.Scenarios.SetState(scenario, newState)
 }
 
+// SaveRequestResponsePair stores the pair, redacted, under the key of its request
 func (this *RequestMatcher) SaveRequestResponsePair(pair *models.RequestResponsePair) error {
-	var key string
+	return this.saveRequestResponsePair(this.GetKey(pair.Request), pair)
+}
 
-	if *this.Webserver {
-		key = pair.IdWithoutHost()
-	} else {
-		key = pair.Id()
-	}
+func (this *RequestMatcher) saveRequestResponsePair(requestKey string, pair *models.RequestResponsePair) error {
+	key := models.ScenarioKey(requestKey, pair.Scenario, pair.RequiredScenarioState)
 
 	log.WithFields(log.Fields{
 		"path":          pair.Request.Path,
@@ -106,21 +172,64 @@ func (this *RequestMatcher) SaveRequestResponsePair(pair *models.RequestResponse
 		"hashKey":       key,
 	}).Debug("Capturing")
 
-	pairBytes, err := pair.Encode()
+	redacted := this.Redaction.Apply(*pair)
+	pairBytes, err := redacted.Encode()
 
 	if err != nil {
 		return err
 	}
 
+	this.Scenarios.RegisterKey(pair.Scenario, requestKey)
+
 	return this.RequestCache.Set([]byte(key), pairBytes)
 }
 
+// RegisterScenarios makes every scenario used by a pair in the request cache known,
+// so that recorded requests in them can be found. It needs calling again when the
+// keys of the pairs change.
+func (this *RequestMatcher) RegisterScenarios() error {
+	values, err := this.RequestCache.GetAllValues()
+	if err != nil {
+		return err
+	}
+
+	this.Scenarios.forgetKeys()
+
+	for _, value := range values {
+		pair, err := models.NewRequestResponsePairFromBytes(value)
+		if err != nil {
+			continue
+		}
+		this.Scenarios.RegisterKey(pair.Scenario, this.GetKey(pair.Request))
+	}
+	return nil
+}
+
 type MatchingError struct {
 	StatusCode  int
 	Description string
+
+	// finding the closest miss reads every recorded request, so it is only
+	// done when the error is reported rather than on every miss
+	findClosestMiss func() *ClosestMiss
+	closestMiss     *ClosestMiss
+	searched        bool
 }
 
-func (this MatchingError) Error() string {
+// ClosestMiss returns the recorded request or request template which came closest
+// to matching, working it out the first time it is asked for
+func (this *MatchingError) ClosestMiss() *ClosestMiss {
+	if !this.searched && this.findClosestMiss != nil {
+		this.closestMiss = this.findClosestMiss()
+		this.searched = true
+	}
+	return this.closestMiss
+}
+
+func (this *MatchingError) Error() string {
+	if closestMiss := this.ClosestMiss(); closestMiss != nil {
+		return this.Description + "\n\n" + closestMiss.String()
+	}
 	return this.Description
 }
 
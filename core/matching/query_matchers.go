@@ -0,0 +1,65 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// queryMatch checks the raw query against an optional request template query.
+// Parameters are sorted by name on both sides first, so the order they are sent
+// in does not matter. Regular expressions are matched against the query as sent.
+func queryMatch(templateQuery *string, query string) bool {
+	if templateQuery == nil {
+		return true
+	}
+
+	matcher := NewFieldMatcher(*templateQuery)
+	if matcher.Type == RegexMatch {
+		return matcher.Match(query)
+	}
+
+	matcher.Value = models.SortQuery(matcher.Value)
+	return matcher.Match(models.SortQuery(query))
+}
+
+// queryParamsMatch checks individual query parameters. Each template value has to
+// match one of the values of the parameter, and a parameter with no template
+// values must not be in the query at all.
+func queryParamsMatch(templateParams map[string][]string, query string) bool {
+	if len(templateParams) == 0 {
+		return true
+	}
+
+	params := models.ParseQuery(query)
+
+	for name, templateValues := range templateParams {
+		values, present := params[name]
+
+		if len(templateValues) == 0 {
+			if present {
+				return false
+			}
+			continue
+		}
+
+		if !present {
+			return false
+		}
+
+		for _, templateValue := range templateValues {
+			matcher := NewFieldMatcher(templateValue)
+
+			matched := false
+			for _, value := range values {
+				if matcher.Match(value) {
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package matching
+
+import (
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func Test_queryMatch_IgnoresTheOrderOfParameters(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(queryMatch(StringToPointer("a=1&b=2"), "b=2&a=1")).To(BeTrue())
+	Expect(queryMatch(StringToPointer("exact:b=2&a=1"), "a=1&b=2")).To(BeTrue())
+	Expect(queryMatch(StringToPointer("a=*&b=2"), "b=2&a=99")).To(BeTrue())
+	Expect(queryMatch(StringToPointer("a=1&b=2"), "a=1&b=3")).To(BeFalse())
+	Expect(queryMatch(nil, "a=1")).To(BeTrue())
+}
+
+func Test_queryMatch_MatchesRegexAgainstTheQueryAsSent(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(queryMatch(StringToPointer("regex:^b=2&a=1$"), "b=2&a=1")).To(BeTrue())
+	Expect(queryMatch(StringToPointer("regex:^a=1&b=2$"), "b=2&a=1")).To(BeFalse())
+}
+
+func Test_queryParamsMatch_MatchesValuesOfEachParameter(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(queryParamsMatch(map[string][]string{"id": []string{"exact:2"}}, "a=1&id=1&id=2")).To(BeTrue())
+	Expect(queryParamsMatch(map[string][]string{"id": []string{"1", "2"}}, "id=2&id=1")).To(BeTrue())
+	Expect(queryParamsMatch(map[string][]string{"id": []string{"regex:^[0-9]+$"}}, "id=abc")).To(BeFalse())
+	Expect(queryParamsMatch(map[string][]string{"name": []string{"exact:a b"}}, "name=a+b")).To(BeTrue())
+}
+
+func Test_queryParamsMatch_GlobMatchesAParameterWhichIsPresent(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(queryParamsMatch(map[string][]string{"flag": []string{"*"}}, "flag")).To(BeTrue())
+	Expect(queryParamsMatch(map[string][]string{"flag": []string{"*"}}, "other=1")).To(BeFalse())
+}
+
+func Test_queryParamsMatch_EmptyValuesMatchAParameterWhichIsAbsent(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(queryParamsMatch(map[string][]string{"debug": []string{}}, "a=1")).To(BeTrue())
+	Expect(queryParamsMatch(map[string][]string{"debug": []string{}}, "a=1&debug=true")).To(BeFalse())
+}
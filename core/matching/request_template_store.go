@@ -1,15 +1,18 @@
 package matching
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
 	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/templating"
 	. "github.com/SpectoLabs/hoverfly/core/util"
-	"github.com/ryanuber/go-glob"
+	"sort"
 	"strings"
-	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 )
 
 type RequestTemplateStore []RequestTemplateResponsePair
@@ -17,6 +20,14 @@ type RequestTemplateStore []RequestTemplateResponsePair
 type RequestTemplateResponsePair struct {
 	RequestTemplate RequestTemplate        `json:"requestTemplate"`
 	Response        models.ResponseDetails `json:"response"`
+	// Priority - templates with a higher priority are matched first, the default is 0
+	Priority int `json:"priority,omitempty"`
+	// Scenario - the name of the scenario the template is part of, if any
+	Scenario string `json:"scenario,omitempty"`
+	// RequiredScenarioState - the state the scenario has to be in for the template to match
+	RequiredScenarioState string `json:"requiredScenarioState,omitempty"`
+	// NewScenarioState - the state the scenario moves to once the template has matched
+	NewScenarioState string `json:"newScenarioState,omitempty"`
 }
 
 type RequestTemplate struct {
@@ -27,41 +38,134 @@ type RequestTemplate struct {
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
 	Headers     map[string][]string `json:"headers"`
+	// QueryParams - matchers for individual query parameters. An empty list of
+	// values means the parameter must not be in the request.
+	QueryParams map[string][]string `json:"queryParams,omitempty"`
+}
+
+// NewRequestTemplateFromView builds a template from the request of a template view
+func NewRequestTemplateFromView(request interfaces.Request) RequestTemplate {
+	body := request.GetBody()
+	if body != nil && request.GetEncodedBody() {
+		decoded, _ := base64.StdEncoding.DecodeString(*body)
+		body = StringToPointer(string(decoded))
+	}
+
+	return RequestTemplate{
+		Path:        request.GetPath(),
+		Method:      request.GetMethod(),
+		Destination: request.GetDestination(),
+		Scheme:      request.GetScheme(),
+		Query:       request.GetQuery(),
+		Body:        body,
+		Headers:     request.GetHeaders(),
+		QueryParams: request.GetQueryParams(),
+	}
+}
+
+// ConvertToRequestDetailsView returns the template as the request of a view
+func (this RequestTemplate) ConvertToRequestDetailsView() *v1.RequestDetailsView {
+	return &v1.RequestDetailsView{
+		Path:        this.Path,
+		Method:      this.Method,
+		Destination: this.Destination,
+		Scheme:      this.Scheme,
+		Query:       this.Query,
+		Body:        this.Body,
+		Headers:     this.Headers,
+		QueryParams: this.QueryParams,
+	}
+}
+
+// Matches returns true when every field set in the template matches the request
+func (this RequestTemplate) Matches(req models.RequestDetails, webserver bool) bool {
+	missed, _ := this.missedFields(req, webserver)
+	return len(missed) == 0
 }
 
 func (this *RequestTemplateStore) GetResponse(req models.RequestDetails, webserver bool) (*models.ResponseDetails, error) {
+	pair, err := this.getPair(req, webserver, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &pair.Response, nil
+}
+
+// getPair returns the first template to match the request while its scenario,
+// if it has one, is in the required state
+func (this *RequestTemplateStore) getPair(req models.RequestDetails, webserver bool, scenarios *ScenarioState) (*RequestTemplateResponsePair, error) {
 	// iterate through the request templates, looking for template to match request
 	for _, entry := range *this {
-		// TODO: not matching by default on URL and body - need to enable this
-		// TODO: need to enable regex matches
-		// TODO: enable matching on scheme
-
-		if entry.RequestTemplate.Body != nil && !glob.Glob(*entry.RequestTemplate.Body, req.Body) {
+		if !scenarios.inRequiredState(entry.Scenario, entry.RequiredScenarioState) {
+			continue
+		}
+		if missed, _ := entry.RequestTemplate.missedFields(req, webserver); len(missed) > 0 {
 			continue
 		}
 
-		if !webserver {
-			if entry.RequestTemplate.Destination != nil && !glob.Glob(*entry.RequestTemplate.Destination, req.Destination) {
-				continue
-			}
+		// return the first template to match
+		pair := entry
+		return &pair, nil
+	}
+	return nil, errors.New("No match found")
+}
+
+// closestMiss returns the template which came closest to matching the request
+func (this RequestTemplateStore) closestMiss(req models.RequestDetails, webserver bool, scenarios *ScenarioState) *ClosestMiss {
+	var closest *ClosestMiss
+	for _, entry := range this {
+		template := entry.RequestTemplate
+		missed, matched := template.missedFields(req, webserver)
+		if !scenarios.inRequiredState(entry.Scenario, entry.RequiredScenarioState) {
+			missed = append(missed, "scenario state")
 		}
-		if entry.RequestTemplate.Path != nil && !glob.Glob(*entry.RequestTemplate.Path, req.Path) {
-			continue
+
+		candidate := &ClosestMiss{
+			RequestTemplate: &template,
+			Response:        entry.Response,
+			MissedFields:    missed,
+			matchedFields:   matched,
 		}
-		if entry.RequestTemplate.Query != nil && !glob.Glob(*entry.RequestTemplate.Query, req.Query) {
-			continue
+		if candidate.closerThan(closest) {
+			closest = candidate
 		}
-		if !headerMatch(entry.RequestTemplate.Headers, req.Headers) {
-			continue
+	}
+	return closest
+}
+
+// missedFields compares the request with every field set in the template. It returns
+// the fields which did not match and a count of the fields which did.
+func (this RequestTemplate) missedFields(req models.RequestDetails, webserver bool) (missed []string, matched int) {
+	check := func(field string, ok bool, set bool) {
+		if !set {
+			return
 		}
-		if entry.RequestTemplate.Method != nil && !glob.Glob(*entry.RequestTemplate.Method, req.Method) {
-			continue
+		if ok {
+			matched++
+		} else {
+			missed = append(missed, field)
 		}
+	}
 
-		// return the first template to match
-		return &entry.Response, nil
+	check("scheme", schemeMatch(this.Scheme, req.Scheme), this.Scheme != nil)
+	if !webserver {
+		check("destination", fieldMatch(this.Destination, req.Destination), this.Destination != nil)
 	}
-	return nil, errors.New("No match found")
+	check("method", fieldMatch(this.Method, req.Method), this.Method != nil)
+	check("path", fieldMatch(this.Path, req.Path), this.Path != nil)
+	check("query", queryMatch(this.Query, req.Query), this.Query != nil)
+
+	for _, name := range sortedKeys(this.QueryParams) {
+		check("query param "+name, queryParamsMatch(map[string][]string{name: this.QueryParams[name]}, req.Query), true)
+	}
+
+	for _, name := range sortedKeys(this.Headers) {
+		check("header "+name, headerMatch(map[string][]string{name: this.Headers[name]}, req.Headers), true)
+	}
+
+	check("body", bodyMatch(this.Body, req), this.Body != nil)
+
+	return missed, matched
 }
 
 // ImportPayloads - a function to save given payloads into the database.
@@ -69,12 +173,39 @@ func (this *RequestTemplateStore) ImportPayloads(pairPayload v1.RequestTemplateR
 	if len(*pairPayload.Data) > 0 {
 		// Convert PayloadView back to Payload for internal storage
 		templateStore := ConvertPayloadToRequestTemplateStore(pairPayload)
+		for _, pl := range templateStore {
+			if err := pl.RequestTemplate.Validate(); err != nil {
+				return err
+			}
+			if pl.Response.Templated {
+				if err := templating.Validate(pl.Response); err != nil {
+					return fmt.Errorf("Response template: %s", err.Error())
+				}
+			}
+			if pl.Response.Fault != nil {
+				if err := pl.Response.Fault.Validate(); err != nil {
+					return err
+				}
+			}
+			if pl.Response.Delay != nil {
+				if err := pl.Response.Delay.Validate(); err != nil {
+					return err
+				}
+			}
+			if err := models.ValidateWebSocketMessages(pl.Response.WebSocketMessages); err != nil {
+				return err
+			}
+			if err := ValidateWebSocketMatchers(pl.Response.WebSocketMessages); err != nil {
+				return err
+			}
+		}
+
 		for _, pl := range templateStore {
 
 			//TODO: add hooks for concsistency with request import
 			// note that importing hoverfly is a disallowed circular import
 
-			*this = append(*this, pl)
+			this.AddRequestTemplateResponsePair(pl)
 		}
 		log.WithFields(log.Fields{
 			"total": len(*this),
@@ -84,32 +215,94 @@ func (this *RequestTemplateStore) ImportPayloads(pairPayload v1.RequestTemplateR
 	return fmt.Errorf("Bad request. Nothing to import!")
 }
 
+// AddRequestTemplateResponsePair adds a template to the store, keeping the store in
+// the order templates are matched in. Higher priorities come first, then templates
+// with more constrained fields, then templates in the order they were added.
+func (this *RequestTemplateStore) AddRequestTemplateResponsePair(pair RequestTemplateResponsePair) {
+	*this = append(*this, pair)
+
+	store := *this
+	sort.SliceStable(store, func(i, j int) bool {
+		if store[i].Priority != store[j].Priority {
+			return store[i].Priority > store[j].Priority
+		}
+		return store[i].RequestTemplate.specificity() > store[j].RequestTemplate.specificity()
+	})
+}
+
+// specificity counts the fields of the template which constrain a match. A glob
+// which matches everything does not count.
+func (this RequestTemplate) specificity() int {
+	constrains := func(value string) bool {
+		return value != "*"
+	}
+
+	specificity := 0
+	for _, value := range []*string{this.Path, this.Method, this.Destination, this.Scheme, this.Query, this.Body} {
+		if value != nil && constrains(*value) {
+			specificity++
+		}
+	}
+
+	for _, values := range this.Headers {
+		for _, value := range values {
+			if constrains(value) {
+				specificity++
+			}
+		}
+	}
+
+	for _, values := range this.QueryParams {
+		if len(values) == 0 {
+			specificity++
+		}
+		for _, value := range values {
+			if constrains(value) {
+				specificity++
+			}
+		}
+	}
+
+	return specificity
+}
+
 func (this *RequestTemplateStore) Wipe() {
 	// don't change the pointer here!
 	*this = RequestTemplateStore{}
+	clearRegexCache()
 }
 
 /**
-Check keys and corresponding values in template headers are also present in request headers
+Check keys and corresponding values in template headers are also present in request headers.
+The request headers are left as they are, they are often those of the request being served.
 */
 func headerMatch(templateHeaders, requestHeaders map[string][]string) bool {
 
-	for templateHeaderKey, templateHeaderValues := range templateHeaders {
-		for requestHeaderKey, requestHeaderValues := range requestHeaders {
-			delete(requestHeaders, requestHeaderKey)
-			requestHeaders[strings.ToLower(requestHeaderKey)] = requestHeaderValues
-
-		}
+	lowerCaseHeaders := make(map[string][]string, len(requestHeaders))
+	for requestHeaderKey, requestHeaderValues := range requestHeaders {
+		key := strings.ToLower(requestHeaderKey)
+		lowerCaseHeaders[key] = append(lowerCaseHeaders[key], requestHeaderValues...)
+	}
 
-		requestTemplateValues, templateHeaderMatched := requestHeaders[strings.ToLower(templateHeaderKey)]
+	for templateHeaderKey, templateHeaderValues := range templateHeaders {
+		requestTemplateValues, templateHeaderMatched := lowerCaseHeaders[strings.ToLower(templateHeaderKey)]
 		if !templateHeaderMatched {
 			return false
 		}
 
 		for _, templateHeaderValue := range templateHeaderValues {
+			matcher := NewFieldMatcher(templateHeaderValue)
+			if matcher.Type == GlobMatch {
+				// header values have always been globbed without regard to case
+				matcher.Value = strings.ToLower(matcher.Value)
+			}
+
 			templateValueMatched := false
 			for _, requestHeaderValue := range requestTemplateValues {
-				if glob.Glob(strings.ToLower(templateHeaderValue), strings.ToLower(requestHeaderValue)) {
+				if matcher.Type == GlobMatch {
+					requestHeaderValue = strings.ToLower(requestHeaderValue)
+				}
+				if matcher.Match(requestHeaderValue) {
 					templateValueMatched = true
 				}
 			}
@@ -122,6 +315,15 @@ func headerMatch(templateHeaders, requestHeaders map[string][]string) bool {
 	return true
 }
 
+func sortedKeys(values map[string][]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (this RequestTemplateStore) GetPayload() v1.RequestTemplateResponsePairPayload {
 	var pairsPayload []v1.RequestTemplateResponsePairView
 	for _, pair := range this {
@@ -142,8 +344,13 @@ func (this *RequestTemplateResponsePair) ConvertToRequestTemplateResponsePairVie
 			Query:       this.RequestTemplate.Query,
 			Body:        this.RequestTemplate.Body,
 			Headers:     this.RequestTemplate.Headers,
+			QueryParams: this.RequestTemplate.QueryParams,
 		},
-		Response: this.Response.ConvertToV1ResponseDetailsView(),
+		Response:              this.Response.ConvertToV1ResponseDetailsView(),
+		Priority:              this.Priority,
+		Scenario:              this.Scenario,
+		RequiredScenarioState: this.RequiredScenarioState,
+		NewScenarioState:      this.NewScenarioState,
 	}
 }
 
@@ -160,8 +367,13 @@ func (this *RequestTemplateResponsePair) ConvertToV1RequestResponsePairView() v1
 			Query:       this.RequestTemplate.Query,
 			Body:        this.RequestTemplate.Body,
 			Headers:     this.RequestTemplate.Headers,
+			QueryParams: this.RequestTemplate.QueryParams,
 		},
-		Response: this.Response.ConvertToV1ResponseDetailsView(),
+		Response:              this.Response.ConvertToV1ResponseDetailsView(),
+		Priority:              this.Priority,
+		Scenario:              this.Scenario,
+		RequiredScenarioState: this.RequiredScenarioState,
+		NewScenarioState:      this.NewScenarioState,
 	}
 }
 
@@ -177,8 +389,13 @@ func (this *RequestTemplateResponsePair) ConvertToRequestResponsePairView() v2.R
 			Query:       this.RequestTemplate.Query,
 			Body:        this.RequestTemplate.Body,
 			Headers:     this.RequestTemplate.Headers,
+			QueryParams: this.RequestTemplate.QueryParams,
 		},
-		Response: this.Response.ConvertToResponseDetailsView(),
+		Response:              this.Response.ConvertToResponseDetailsView(),
+		Priority:              this.Priority,
+		Scenario:              this.Scenario,
+		RequiredScenarioState: this.RequiredScenarioState,
+		NewScenarioState:      this.NewScenarioState,
 	}
 }
 
@@ -200,7 +417,12 @@ func ConvertToRequestTemplateResponsePair(pairView v1.RequestTemplateResponsePai
 			Query:       pairView.RequestTemplate.Query,
 			Body:        pairView.RequestTemplate.Body,
 			Headers:     pairView.RequestTemplate.Headers,
+			QueryParams: pairView.RequestTemplate.QueryParams,
 		},
-		Response: models.NewResponseDetailsFromResponse(pairView.Response),
+		Response:              models.NewResponseDetailsFromResponse(pairView.Response),
+		Priority:              pairView.Priority,
+		Scenario:              pairView.Scenario,
+		RequiredScenarioState: pairView.RequiredScenarioState,
+		NewScenarioState:      pairView.NewScenarioState,
 	}
 }
@@ -1,6 +1,7 @@
 package matching
 
 import (
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	. "github.com/SpectoLabs/hoverfly/core/util"
 	. "github.com/onsi/gomega"
@@ -238,6 +239,24 @@ func TestIgnoreTestCaseInsensitiveHeaderMatch(t *testing.T) {
 	Expect(res).To(BeTrue())
 }
 
+func TestHeaderMatch_LeavesTheRequestHeadersAsTheyWere(t *testing.T) {
+	RegisterTestingT(t)
+
+	tmplHeaders := map[string][]string{
+		"header1": []string{"val1"},
+	}
+	reqHeaders := map[string][]string{
+		"Header1":  []string{"val1"},
+		"X-Custom": []string{"custom"},
+	}
+
+	Expect(headerMatch(tmplHeaders, reqHeaders)).To(BeTrue())
+	Expect(reqHeaders).To(Equal(map[string][]string{
+		"Header1":  []string{"val1"},
+		"X-Custom": []string{"custom"},
+	}))
+}
+
 func TestHeaderMatchingTemplateHasMoreHeaderKeysThanRequestMatchesFalse(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -683,3 +702,278 @@ func TestRequestTemplateResponsePair_ConvertToRequestResponsePairView_CanBeConve
 
 	Expect(pairView.Response.Body).To(Equal("template matched"))
 }
+
+func TestTemplatesCanUseRegexOnPathAndBeMatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	templateEntry := RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{
+			Path: StringToPointer("regex:^/users/[0-9]+/orders$"),
+		},
+		Response: models.ResponseDetails{
+			Body: "body",
+		},
+	}
+
+	store := RequestTemplateStore{templateEntry}
+
+	result, err := store.GetResponse(models.RequestDetails{Path: "/users/123/orders"}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+
+	result, err = store.GetResponse(models.RequestDetails{Path: "/users/abc/orders"}, false)
+	Expect(err).ToNot(BeNil())
+	Expect(result).To(BeNil())
+}
+
+func TestTemplatesCanUseExactMatchOnQueryAndNotBeGlobbed(t *testing.T) {
+	RegisterTestingT(t)
+
+	templateEntry := RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{
+			Query: StringToPointer("exact:q=*"),
+		},
+		Response: models.ResponseDetails{
+			Body: "body",
+		},
+	}
+
+	store := RequestTemplateStore{templateEntry}
+
+	result, err := store.GetResponse(models.RequestDetails{Query: "q=anything"}, false)
+	Expect(err).ToNot(BeNil())
+	Expect(result).To(BeNil())
+
+	result, err = store.GetResponse(models.RequestDetails{Query: "q=*"}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+}
+
+func TestTemplatesCanUseCaseInsensitiveMatchOnMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	templateEntry := RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{
+			Method: StringToPointer("case-insensitive:get"),
+		},
+		Response: models.ResponseDetails{
+			Body: "body",
+		},
+	}
+
+	store := RequestTemplateStore{templateEntry}
+
+	result, err := store.GetResponse(models.RequestDetails{Method: "GET"}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+}
+
+func TestTemplatesCanUseRegexOnHeadersAndBeMatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	templateEntry := RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{
+			Headers: map[string][]string{
+				"Authorization": []string{"regex:^Bearer [a-z]+$"},
+			},
+		},
+		Response: models.ResponseDetails{
+			Body: "body",
+		},
+	}
+
+	store := RequestTemplateStore{templateEntry}
+
+	result, err := store.GetResponse(models.RequestDetails{
+		Headers: map[string][]string{
+			"Authorization": []string{"Bearer token"},
+		},
+	}, false)
+	Expect(err).To(BeNil())
+	Expect(result.Body).To(Equal("body"))
+
+	result, err = store.GetResponse(models.RequestDetails{
+		Headers: map[string][]string{
+			"Authorization": []string{"Basic token"},
+		},
+	}, false)
+	Expect(err).ToNot(BeNil())
+	Expect(result).To(BeNil())
+}
+
+func TestRequestTemplateStore_ImportPayloads_ReturnsErrorForInvalidRegex(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{}
+
+	err := store.ImportPayloads(v1.RequestTemplateResponsePairPayload{
+		Data: &[]v1.RequestTemplateResponsePairView{
+			{
+				RequestTemplate: v1.RequestTemplateView{
+					Path: StringToPointer("regex:/users/[0-9+"),
+				},
+			},
+		},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("path"))
+	Expect(store).To(HaveLen(0))
+}
+
+func TestRequestTemplateStore_GetResponse_MatchesQueryParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				QueryParams: map[string][]string{
+					"page":  []string{"regex:^[0-9]+$"},
+					"debug": []string{},
+				},
+			},
+			Response: models.ResponseDetails{
+				Body: "page",
+			},
+		},
+	}
+
+	response, err := store.GetResponse(models.RequestDetails{Path: "/items", Query: "size=10&page=2"}, false)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("page"))
+
+	_, err = store.GetResponse(models.RequestDetails{Path: "/items", Query: "page=2&debug=1"}, false)
+	Expect(err).ToNot(BeNil())
+}
+
+func TestRequestTemplateStore_ImportPayloads_RejectsInvalidQueryParamMatchers(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{}
+
+	err := store.ImportPayloads(v1.RequestTemplateResponsePairPayload{
+		Data: &[]v1.RequestTemplateResponsePairView{
+			{
+				RequestTemplate: v1.RequestTemplateView{
+					QueryParams: map[string][]string{"page": []string{"regex:["}},
+				},
+			},
+		},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(store).To(HaveLen(0))
+}
+
+func TestRequestTemplateStore_GetResponse_MatchesScheme(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				Destination: StringToPointer("test.com"),
+				Scheme:      StringToPointer("https"),
+			},
+			Response: models.ResponseDetails{
+				Body: "secure",
+			},
+		},
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{
+				Destination: StringToPointer("test.com"),
+				Scheme:      StringToPointer("http"),
+			},
+			Response: models.ResponseDetails{
+				Body: "insecure",
+			},
+		},
+	}
+
+	response, err := store.GetResponse(models.RequestDetails{Destination: "test.com", Scheme: "http"}, false)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("insecure"))
+
+	response, err = store.GetResponse(models.RequestDetails{Destination: "test.com", Scheme: "HTTPS"}, false)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("secure"))
+}
+
+func TestRequestTemplateStore_GetResponse_MatchesHigherPriorityTemplatesFirst(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{}
+	store.AddRequestTemplateResponsePair(RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{Path: StringToPointer("/users/1")},
+		Response:        models.ResponseDetails{Body: "specific"},
+	})
+	store.AddRequestTemplateResponsePair(RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{Path: StringToPointer("*")},
+		Response:        models.ResponseDetails{Body: "fallback"},
+		Priority:        1,
+	})
+
+	response, err := store.GetResponse(models.RequestDetails{Path: "/users/1"}, false)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("fallback"))
+}
+
+func TestRequestTemplateStore_GetResponse_MatchesTheMostSpecificTemplateWhenPrioritiesTie(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{}
+	store.AddRequestTemplateResponsePair(RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{Path: StringToPointer("*"), Method: StringToPointer("GET")},
+		Response:        models.ResponseDetails{Body: "broad"},
+	})
+	store.AddRequestTemplateResponsePair(RequestTemplateResponsePair{
+		RequestTemplate: RequestTemplate{Path: StringToPointer("/users/*"), Method: StringToPointer("GET")},
+		Response:        models.ResponseDetails{Body: "specific"},
+	})
+
+	response, err := store.GetResponse(models.RequestDetails{Path: "/users/1", Method: "GET"}, false)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("specific"))
+}
+
+func TestRequestTemplateStore_GetPayload_ReturnsTemplatesInTheOrderTheyAreMatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	store := RequestTemplateStore{}
+	err := store.ImportPayloads(v1.RequestTemplateResponsePairPayload{
+		Data: &[]v1.RequestTemplateResponsePairView{
+			{RequestTemplate: v1.RequestTemplateView{Path: StringToPointer("one")}},
+			{RequestTemplate: v1.RequestTemplateView{Path: StringToPointer("two")}, Priority: 5},
+			{RequestTemplate: v1.RequestTemplateView{Path: StringToPointer("three"), Method: StringToPointer("GET")}},
+			{RequestTemplate: v1.RequestTemplateView{Path: StringToPointer("four")}},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	payload := store.GetPayload()
+
+	var paths []string
+	for _, pair := range *payload.Data {
+		paths = append(paths, *pair.RequestTemplate.Path)
+	}
+	Expect(paths).To(Equal([]string{"two", "three", "one", "four"}))
+	Expect((*payload.Data)[0].Priority).To(Equal(5))
+}
+
+func TestNewRequestTemplateFromView_MatchesLikeATemplate(t *testing.T) {
+	RegisterTestingT(t)
+
+	template := NewRequestTemplateFromView(v1.RequestDetailsView{
+		Method:  StringToPointer("GET"),
+		Headers: map[string][]string{"X-Tenant": []string{"big"}},
+	})
+
+	Expect(template.Matches(models.RequestDetails{
+		Method:  "GET",
+		Headers: map[string][]string{"X-Tenant": []string{"big"}},
+	}, false)).To(BeTrue())
+
+	Expect(template.Matches(models.RequestDetails{
+		Method:  "GET",
+		Headers: map[string][]string{"X-Tenant": []string{"small"}},
+	}, false)).To(BeFalse())
+}
@@ -0,0 +1,190 @@
+package matching
+
+import (
+	"sort"
+	"sync"
+)
+
+// ScenarioStarted is the state every scenario is in until a matched pair or
+// template moves it on
+const ScenarioStarted = "Started"
+
+// ScenarioState holds the current state of every scenario. A nil ScenarioState
+// behaves as if every scenario is in the started state.
+type ScenarioState struct {
+	mutex sync.Mutex
+	// matching - held while a request is matched and its scenario moved on, so
+	// that concurrent requests cannot both be matched in the same state
+	matching sync.Mutex
+	states   map[string]string
+	// keys - the scenarios which have recorded requests for each request key,
+	// so a lookup only tries the scenarios the request can be in
+	keys map[string][]string
+}
+
+func NewScenarioState() *ScenarioState {
+	return &ScenarioState{
+		states: map[string]string{},
+		keys:   map[string][]string{},
+	}
+}
+
+// Register makes a scenario known so that its state can be listed and recorded
+// requests in it can be looked up
+func (this *ScenarioState) Register(scenario string) {
+	if this == nil || scenario == "" {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if _, ok := this.states[scenario]; !ok {
+		this.states[scenario] = ScenarioStarted
+	}
+}
+
+// RegisterKey registers a scenario which has a recorded request with the key,
+// the key being that of the request before the scenario is added to it
+func (this *ScenarioState) RegisterKey(scenario, key string) {
+	if this == nil || scenario == "" {
+		return
+	}
+
+	this.Register(scenario)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for _, registered := range this.keys[key] {
+		if registered == scenario {
+			return
+		}
+	}
+	this.keys[key] = append(this.keys[key], scenario)
+	sort.Strings(this.keys[key])
+}
+
+// ScenariosFor returns the scenarios which have a recorded request with the key, sorted
+func (this *ScenarioState) ScenariosFor(key string) []string {
+	if this == nil {
+		return nil
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return append([]string{}, this.keys[key]...)
+}
+
+// forgetKeys clears the scenarios registered for each key, leaving their states
+func (this *ScenarioState) forgetKeys() {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.keys = map[string][]string{}
+}
+
+func (this *ScenarioState) lockMatching() {
+	if this != nil {
+		this.matching.Lock()
+	}
+}
+
+func (this *ScenarioState) unlockMatching() {
+	if this != nil {
+		this.matching.Unlock()
+	}
+}
+
+func (this *ScenarioState) GetState(scenario string) string {
+	if this == nil {
+		return ScenarioStarted
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if state, ok := this.states[scenario]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+func (this *ScenarioState) SetState(scenario, state string) {
+	if this == nil || scenario == "" {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.states[scenario] = state
+}
+
+// GetAll returns a copy of the current state of every known scenario
+func (this *ScenarioState) GetAll() map[string]string {
+	states := map[string]string{}
+	if this == nil {
+		return states
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for scenario, state := range this.states {
+		states[scenario] = state
+	}
+	return states
+}
+
+// Names returns every known scenario, sorted
+func (this *ScenarioState) Names() []string {
+	var names []string
+	for scenario := range this.GetAll() {
+		names = append(names, scenario)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResetAll puts every scenario back into the started state
+func (this *ScenarioState) ResetAll() {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for scenario := range this.states {
+		this.states[scenario] = ScenarioStarted
+	}
+}
+
+// Wipe forgets every scenario
+func (this *ScenarioState) Wipe() {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.states = map[string]string{}
+	this.keys = map[string][]string{}
+}
+
+// inRequiredState checks whether a scenario is in the state a pair or template
+// requires. Pairs and templates which are not in a scenario, or which do not
+// require a state, are always in the required state.
+func (this *ScenarioState) inRequiredState(scenario, requiredState string) bool {
+	if scenario == "" || requiredState == "" {
+		return true
+	}
+	return this.GetState(scenario) == requiredState
+}
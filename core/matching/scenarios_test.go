@@ -0,0 +1,231 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestScenarioState_ScenariosStartInTheStartedState(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewScenarioState()
+	unit.Register("order")
+
+	Expect(unit.GetState("order")).To(Equal(ScenarioStarted))
+	Expect(unit.GetState("unknown")).To(Equal(ScenarioStarted))
+	Expect(unit.Names()).To(Equal([]string{"order"}))
+}
+
+func TestScenarioState_ResetAll_PutsEveryScenarioBackIntoTheStartedState(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewScenarioState()
+	unit.SetState("order", "SHIPPED")
+	unit.SetState("payment", "FAILED")
+
+	unit.ResetAll()
+
+	Expect(unit.GetAll()).To(Equal(map[string]string{
+		"order":   ScenarioStarted,
+		"payment": ScenarioStarted,
+	}))
+}
+
+func TestScenarioState_NilScenarioStateIsAlwaysStarted(t *testing.T) {
+	RegisterTestingT(t)
+
+	var unit *ScenarioState
+	unit.Register("order")
+	unit.SetState("order", "SHIPPED")
+
+	Expect(unit.GetState("order")).To(Equal(ScenarioStarted))
+	Expect(unit.Names()).To(BeEmpty())
+}
+
+func TestScenarioState_ScenariosFor_OnlyReturnsScenariosRegisteredForTheKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewScenarioState()
+	unit.RegisterKey("payment", "order-key")
+	unit.RegisterKey("order", "order-key")
+	unit.RegisterKey("order", "order-key")
+	unit.RegisterKey("sequence:user-key", "user-key")
+
+	Expect(unit.ScenariosFor("order-key")).To(Equal([]string{"order", "payment"}))
+	Expect(unit.ScenariosFor("user-key")).To(Equal([]string{"sequence:user-key"}))
+	Expect(unit.ScenariosFor("other-key")).To(BeEmpty())
+	Expect(unit.Names()).To(Equal([]string{"order", "payment", "sequence:user-key"}))
+
+	unit.Wipe()
+
+	Expect(unit.ScenariosFor("order-key")).To(BeEmpty())
+}
+
+func TestRequestMatcher_GetResponse_ReturnsRecordedRequestsInScenarioOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	request := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/order/1"}
+
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:               request,
+		Response:              models.ResponseDetails{Status: 200, Body: "PENDING"},
+		Scenario:              "order",
+		RequiredScenarioState: ScenarioStarted,
+		NewScenarioState:      "shipped",
+	})
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:               request,
+		Response:              models.ResponseDetails{Status: 200, Body: "SHIPPED"},
+		Scenario:              "order",
+		RequiredScenarioState: "shipped",
+	})
+
+	response, err := unit.GetResponse(&request)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("PENDING"))
+
+	response, err = unit.GetResponse(&request)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("SHIPPED"))
+
+	response, err = unit.GetResponse(&request)
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("SHIPPED"))
+}
+
+func TestRequestMatcher_PeekResponse_LeavesTheScenarioInItsState(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	request := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/order/1"}
+
+	unit.SaveRequestResponsePair(&models.RequestResponsePair{
+		Request:               request,
+		Response:              models.ResponseDetails{Status: 200, Body: "PENDING"},
+		Scenario:              "order",
+		RequiredScenarioState: ScenarioStarted,
+		NewScenarioState:      "shipped",
+	})
+
+	for i := 0; i < 2; i++ {
+		response, err := unit.PeekResponse(&request)
+		Expect(err).To(BeNil())
+		Expect(response.Body).To(Equal("PENDING"))
+	}
+	Expect(unit.Scenarios.GetState("order")).To(Equal(ScenarioStarted))
+}
+
+func TestRequestMatcher_GetResponse_ReturnsTemplatesInScenarioOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	unit.TemplateStore = RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate:       RequestTemplate{Path: StringToPointer("/pay")},
+			Response:              models.ResponseDetails{Status: 503},
+			Scenario:              "retry",
+			RequiredScenarioState: ScenarioStarted,
+			NewScenarioState:      "failed once",
+		},
+		RequestTemplateResponsePair{
+			RequestTemplate:       RequestTemplate{Path: StringToPointer("/pay")},
+			Response:              models.ResponseDetails{Status: 503},
+			Scenario:              "retry",
+			RequiredScenarioState: "failed once",
+			NewScenarioState:      "failed twice",
+		},
+		RequestTemplateResponsePair{
+			RequestTemplate: RequestTemplate{Path: StringToPointer("/pay")},
+			Response:        models.ResponseDetails{Status: 200},
+		},
+	}
+
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		response, err := unit.GetResponse(&models.RequestDetails{Path: "/pay"})
+		Expect(err).To(BeNil())
+		statuses = append(statuses, response.Status)
+	}
+
+	Expect(statuses).To(Equal([]int{503, 503, 200}))
+	Expect(unit.Scenarios.GetState("retry")).To(Equal("failed twice"))
+}
+
+func TestRequestMatcher_GetResponse_MatchesConcurrentRequestsInTurn(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	requests := 200
+
+	state := ScenarioStarted
+	for i := 0; i < requests; i++ {
+		next := strconv.Itoa(i + 1)
+		unit.TemplateStore = append(unit.TemplateStore, RequestTemplateResponsePair{
+			RequestTemplate:       RequestTemplate{Path: StringToPointer("/ticket")},
+			Response:              models.ResponseDetails{Status: 200, Body: next},
+			Scenario:              "tickets",
+			RequiredScenarioState: state,
+			NewScenarioState:      next,
+		})
+		state = next
+	}
+
+	start := make(chan struct{})
+	bodies := make(chan string, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			response, err := unit.GetResponse(&models.RequestDetails{Path: "/ticket"})
+			if err == nil {
+				bodies <- response.Body
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(bodies)
+
+	seen := map[string]bool{}
+	for body := range bodies {
+		Expect(seen).ToNot(HaveKey(body))
+		seen[body] = true
+	}
+	Expect(seen).To(HaveLen(requests))
+	Expect(unit.Scenarios.GetState("tickets")).To(Equal(strconv.Itoa(requests)))
+}
+
+func TestRequestMatcher_GetResponse_ReportsTheScenarioStateAsAClosestMiss(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	unit.TemplateStore = RequestTemplateStore{
+		RequestTemplateResponsePair{
+			RequestTemplate:       RequestTemplate{Path: StringToPointer("/order")},
+			Scenario:              "order",
+			RequiredScenarioState: "shipped",
+		},
+	}
+
+	_, err := unit.GetResponse(&models.RequestDetails{Path: "/order"})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.ClosestMiss().MissedFields).To(Equal([]string{"scenario state"}))
+}
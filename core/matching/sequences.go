@@ -0,0 +1,58 @@
+package matching
+
+import (
+	"strconv"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// sequenceScenarioPrefix names the scenarios which hold a captured sequence of
+// responses, followed by the key of the request
+const sequenceScenarioPrefix = "sequence:"
+
+// sequenceState returns the scenario state the nth response of a sequence requires,
+// the first response is matched while the scenario is started
+func sequenceState(n int) string {
+	if n == 1 {
+		return ScenarioStarted
+	}
+	return strconv.Itoa(n)
+}
+
+// SaveRequestResponsePairInSequence adds the pair to the end of the sequence of
+// responses captured for its request. Each response moves the sequence on to the
+// next one. The last response either loops back to the first or keeps being returned.
+func (this *RequestMatcher) SaveRequestResponsePairInSequence(pair *models.RequestResponsePair, loop bool) error {
+	key := this.GetKey(pair.Request)
+	scenario := sequenceScenarioPrefix + key
+
+	n := 1
+	var previous *models.RequestResponsePair
+	for {
+		pairBytes, err := this.RequestCache.Get([]byte(models.ScenarioKey(key, scenario, sequenceState(n))))
+		if err != nil {
+			break
+		}
+		if previous, err = models.NewRequestResponsePairFromBytes(pairBytes); err != nil {
+			return err
+		}
+		n++
+	}
+
+	if previous != nil {
+		previous.NewScenarioState = sequenceState(n)
+		// kept under the key of this request, as it has already been redacted
+		if err := this.saveRequestResponsePair(key, previous); err != nil {
+			return err
+		}
+	}
+
+	pair.Scenario = scenario
+	pair.RequiredScenarioState = sequenceState(n)
+	pair.NewScenarioState = ""
+	if loop {
+		pair.NewScenarioState = sequenceState(1)
+	}
+
+	return this.saveRequestResponsePair(key, pair)
+}
@@ -0,0 +1,70 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func saveSequence(unit *RequestMatcher, request models.RequestDetails, loop bool, bodies ...string) {
+	for _, body := range bodies {
+		err := unit.SaveRequestResponsePairInSequence(&models.RequestResponsePair{
+			Request:  request,
+			Response: models.ResponseDetails{Status: 200, Body: body},
+		}, loop)
+		Expect(err).To(BeNil())
+	}
+}
+
+func getBodies(unit *RequestMatcher, request models.RequestDetails, times int) []string {
+	var bodies []string
+	for i := 0; i < times; i++ {
+		response, err := unit.GetResponse(&request)
+		Expect(err).To(BeNil())
+		bodies = append(bodies, response.Body)
+	}
+	return bodies
+}
+
+func TestRequestMatcher_SaveRequestResponsePairInSequence_LoopsBackToTheFirstResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	request := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/poll"}
+	saveSequence(&unit, request, true, "pending", "running", "done")
+
+	Expect(getBodies(&unit, request, 4)).To(Equal([]string{"pending", "running", "done", "pending"}))
+}
+
+func TestRequestMatcher_SaveRequestResponsePairInSequence_SticksOnTheLastResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	request := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/poll"}
+	saveSequence(&unit, request, false, "pending", "done")
+
+	Expect(getBodies(&unit, request, 3)).To(Equal([]string{"pending", "done", "done"}))
+
+	count, err := unit.RequestCache.RecordsCount()
+	Expect(err).To(BeNil())
+	Expect(count).To(Equal(2))
+}
+
+func TestRequestMatcher_SaveRequestResponsePairInSequence_KeepsSequencesOfDifferentRequestsApart(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newTestRequestMatcher()
+	unit.Scenarios = NewScenarioState()
+
+	one := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/one"}
+	two := models.RequestDetails{Destination: "test.com", Method: "GET", Path: "/two"}
+	saveSequence(&unit, one, false, "one-a", "one-b")
+	saveSequence(&unit, two, false, "two-a")
+
+	Expect(getBodies(&unit, two, 2)).To(Equal([]string{"two-a", "two-a"}))
+	Expect(getBodies(&unit, one, 2)).To(Equal([]string{"one-a", "one-b"}))
+}
@@ -0,0 +1,198 @@
+package matching
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/antchfx/xpath"
+)
+
+// xmlNode is a minimal XML document tree which can be walked by an xmlNavigator
+type xmlNode struct {
+	Type   xpath.NodeType
+	Name   string
+	Prefix string
+	Data   string
+	Attr   []xml.Attr
+
+	Parent, FirstChild, LastChild, PrevSibling, NextSibling *xmlNode
+}
+
+func (this *xmlNode) addChild(child *xmlNode) {
+	child.Parent = this
+	if this.LastChild == nil {
+		this.FirstChild = child
+	} else {
+		this.LastChild.NextSibling = child
+		child.PrevSibling = this.LastChild
+	}
+	this.LastChild = child
+}
+
+func (this *xmlNode) innerText() string {
+	if this.Type == xpath.TextNode || this.Type == xpath.CommentNode {
+		return this.Data
+	}
+
+	var buffer bytes.Buffer
+	for child := this.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != xpath.CommentNode {
+			buffer.WriteString(child.innerText())
+		}
+	}
+	return buffer.String()
+}
+
+// parseXML reads an XML document into a tree of xmlNodes, whitespace only
+// text between elements is discarded
+func parseXML(body string) (*xmlNode, error) {
+	root := &xmlNode{Type: xpath.RootNode}
+	current := root
+
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlNode{
+				Type:   xpath.ElementNode,
+				Name:   t.Name.Local,
+				Prefix: t.Name.Space,
+				Attr:   t.Attr,
+			}
+			current.addChild(element)
+			current = element
+		case xml.EndElement:
+			current = current.Parent
+		case xml.CharData:
+			if text := string(t); strings.TrimSpace(text) != "" {
+				current.addChild(&xmlNode{Type: xpath.TextNode, Data: text})
+			}
+		case xml.Comment:
+			current.addChild(&xmlNode{Type: xpath.CommentNode, Data: string(t)})
+		}
+	}
+
+	return root, nil
+}
+
+// xmlNavigator implements xpath.NodeNavigator over an xmlNode tree
+type xmlNavigator struct {
+	root, current *xmlNode
+	attr          int
+}
+
+func newXMLNavigator(root *xmlNode) *xmlNavigator {
+	return &xmlNavigator{root: root, current: root, attr: -1}
+}
+
+func (this *xmlNavigator) NodeType() xpath.NodeType {
+	if this.attr != -1 {
+		return xpath.AttributeNode
+	}
+	return this.current.Type
+}
+
+func (this *xmlNavigator) LocalName() string {
+	if this.attr != -1 {
+		return this.current.Attr[this.attr].Name.Local
+	}
+	return this.current.Name
+}
+
+func (this *xmlNavigator) Prefix() string {
+	if this.attr != -1 {
+		return this.current.Attr[this.attr].Name.Space
+	}
+	return this.current.Prefix
+}
+
+func (this *xmlNavigator) Value() string {
+	if this.attr != -1 {
+		return this.current.Attr[this.attr].Value
+	}
+	return this.current.innerText()
+}
+
+func (this *xmlNavigator) Copy() xpath.NodeNavigator {
+	navigator := *this
+	return &navigator
+}
+
+func (this *xmlNavigator) MoveToRoot() {
+	this.current = this.root
+	this.attr = -1
+}
+
+func (this *xmlNavigator) MoveToParent() bool {
+	if this.attr != -1 {
+		this.attr = -1
+		return true
+	}
+	if this.current.Parent == nil {
+		return false
+	}
+	this.current = this.current.Parent
+	return true
+}
+
+func (this *xmlNavigator) MoveToNextAttribute() bool {
+	if this.attr >= len(this.current.Attr)-1 {
+		return false
+	}
+	this.attr++
+	return true
+}
+
+func (this *xmlNavigator) MoveToChild() bool {
+	if this.attr != -1 || this.current.FirstChild == nil {
+		return false
+	}
+	this.current = this.current.FirstChild
+	return true
+}
+
+func (this *xmlNavigator) MoveToFirst() bool {
+	if this.attr != -1 || this.current.PrevSibling == nil {
+		return false
+	}
+	for this.current.PrevSibling != nil {
+		this.current = this.current.PrevSibling
+	}
+	return true
+}
+
+func (this *xmlNavigator) MoveToNext() bool {
+	if this.attr != -1 || this.current.NextSibling == nil {
+		return false
+	}
+	this.current = this.current.NextSibling
+	return true
+}
+
+func (this *xmlNavigator) MoveToPrevious() bool {
+	if this.attr != -1 || this.current.PrevSibling == nil {
+		return false
+	}
+	this.current = this.current.PrevSibling
+	return true
+}
+
+func (this *xmlNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	navigator, ok := other.(*xmlNavigator)
+	if !ok || navigator.root != this.root {
+		return false
+	}
+	this.current = navigator.current
+	this.attr = navigator.attr
+	return true
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// canonicaliseJSON decodes and re-encodes a JSON document so that structurally equal
+// documents produce the same string. Object keys are sorted and numbers are
+// normalised, so {"b":2.0,"a":1} becomes {"a":1,"b":2}. Integers are kept as
+// written, so large IDs which a float64 cannot hold stay distinct.
+func canonicaliseJSON(body string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return "", err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return "", errors.New("Unexpected data after JSON document")
+	}
+
+	canonical, err := json.Marshal(canonicaliseNumbers(data))
+	if err != nil {
+		return "", err
+	}
+
+	return string(canonical), nil
+}
+
+func canonicaliseNumbers(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = canonicaliseNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = canonicaliseNumbers(child)
+		}
+	case json.Number:
+		return canonicalNumber(value)
+	}
+	return data
+}
+
+// canonicalNumber leaves integers as they were written and writes any other
+// number the way a float64 would be encoded, so 1.0 and 1e0 both become 1
+func canonicalNumber(number json.Number) json.Number {
+	if !strings.ContainsAny(number.String(), ".eE") {
+		return number
+	}
+
+	float, err := number.Float64()
+	if err != nil {
+		return number
+	}
+	encoded, err := json.Marshal(float)
+	if err != nil {
+		return number
+	}
+	return json.Number(encoded)
+}
+
+// canonicaliseXML produces a string for an XML document which ignores attribute order,
+// whitespace between elements, comments and processing instructions. The output is
+// only used for hashing and is not guaranteed to be valid XML.
+func canonicaliseXML(body string) (string, error) {
+	var buffer bytes.Buffer
+
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			attributes := make([]string, len(t.Attr))
+			for i, attribute := range t.Attr {
+				var value bytes.Buffer
+				xml.EscapeText(&value, []byte(attribute.Value))
+				attributes[i] = xmlName(attribute.Name) + `="` + value.String() + `"`
+			}
+			sort.Strings(attributes)
+
+			buffer.WriteString("<" + xmlName(t.Name))
+			for _, attribute := range attributes {
+				buffer.WriteString(" " + attribute)
+			}
+			buffer.WriteString(">")
+		case xml.EndElement:
+			buffer.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				xml.EscapeText(&buffer, []byte(text))
+			}
+		}
+	}
+
+	return buffer.String(), nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
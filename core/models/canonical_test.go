@@ -0,0 +1,104 @@
+package models
+
+import (
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func Test_canonicaliseJSON_SortsKeys(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, err := canonicaliseJSON(`{"a": 1, "b": {"d": [1, 2], "c": true}}`)
+	Expect(err).To(BeNil())
+
+	two, err := canonicaliseJSON(`{"b": {"c": true, "d": [1, 2]}, "a": 1}`)
+	Expect(err).To(BeNil())
+
+	Expect(one).To(Equal(two))
+	Expect(one).To(Equal(`{"a":1,"b":{"c":true,"d":[1,2]}}`))
+}
+
+func Test_canonicaliseJSON_NormalisesNumbers(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, _ := canonicaliseJSON(`{"a": 1}`)
+	two, _ := canonicaliseJSON(`{"a": 1.0}`)
+	three, _ := canonicaliseJSON(`{"a": 1e0}`)
+
+	Expect(one).To(Equal(two))
+	Expect(one).To(Equal(three))
+}
+
+func Test_canonicaliseJSON_KeepsLargeIntegersDistinct(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, err := canonicaliseJSON(`{"id": 9007199254740993}`)
+	Expect(err).To(BeNil())
+
+	two, err := canonicaliseJSON(`{"id": 9007199254740992}`)
+	Expect(err).To(BeNil())
+
+	Expect(one).ToNot(Equal(two))
+	Expect(one).To(Equal(`{"id":9007199254740993}`))
+}
+
+func Test_canonicaliseJSON_KeepsArrayOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, _ := canonicaliseJSON(`[1, 2]`)
+	two, _ := canonicaliseJSON(`[2, 1]`)
+
+	Expect(one).ToNot(Equal(two))
+}
+
+func Test_canonicaliseJSON_ReturnsErrorForInvalidJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := canonicaliseJSON(`{"a": `)
+
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_canonicaliseXML_IgnoresAttributeOrderAndWhitespace(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, err := canonicaliseXML(`<?xml version="1.0"?><order id="1" status="NEW"><item>a</item></order>`)
+	Expect(err).To(BeNil())
+
+	two, err := canonicaliseXML(`<order status="NEW" id="1">
+		<!-- a comment -->
+		<item>a</item>
+	</order>`)
+	Expect(err).To(BeNil())
+
+	Expect(one).To(Equal(two))
+}
+
+func Test_canonicaliseXML_KeepsElementOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	one, _ := canonicaliseXML(`<order><a/><b/></order>`)
+	two, _ := canonicaliseXML(`<order><b/><a/></order>`)
+
+	Expect(one).ToNot(Equal(two))
+}
+
+func TestRequestDetails_Hash_IsTheSameForJSONBodiesWithDifferentKeyOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	one := RequestDetails{
+		Path:    "/orders",
+		Method:  "POST",
+		Body:    `{"a":1,"b":2}`,
+		Headers: map[string][]string{"Content-Type": []string{"application/json"}},
+	}
+
+	two := RequestDetails{
+		Path:    "/orders",
+		Method:  "POST",
+		Body:    `{"b":2,"a":1}`,
+		Headers: map[string][]string{"Content-Type": []string{"application/json"}},
+	}
+
+	Expect(one.Hash()).To(Equal(two.Hash()))
+}
@@ -5,15 +5,38 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
+	"math"
+	"math/rand"
 	"regexp"
 	"strings"
 	"time"
 )
 
+const (
+	DelayDistributionFixed     = "fixed"
+	DelayDistributionUniform   = "uniform"
+	DelayDistributionNormal    = "normal"
+	DelayDistributionLogNormal = "lognormal"
+)
+
+// z-score of the 99th percentile of the standard normal distribution
+const p99ZScore = 2.326
+
+// ResponseDelay holds either a fixed delay or the parameters of a latency
+// distribution, all in milliseconds. Min and Max bound the sampled delay
+// of the normal and log-normal distributions when set.
 type ResponseDelay struct {
-	UrlPattern string `json:"urlPattern"`
-	HttpMethod string `json:"httpMethod"`
-	Delay      int    `json:"delay"`
+	UrlPattern   string `json:"urlPattern"`
+	HttpMethod   string `json:"httpMethod"`
+	Delay        int    `json:"delay"`
+	Distribution string `json:"distribution,omitempty"`
+	Min          int    `json:"min,omitempty"`
+	Max          int    `json:"max,omitempty"`
+	Mean         int    `json:"mean,omitempty"`
+	StdDev       int    `json:"stdDev,omitempty"`
+	Median       int    `json:"median,omitempty"`
+	P99          int    `json:"p99,omitempty"`
 }
 
 type ResponseDelayList []ResponseDelay
@@ -24,36 +47,177 @@ type ResponseDelays interface {
 	ConvertToResponseDelayPayloadView() v1.ResponseDelayPayloadView
 }
 
+func NewResponseDelayFromView(view v1.ResponseDelayView) ResponseDelay {
+	return ResponseDelay{
+		UrlPattern:   view.UrlPattern,
+		HttpMethod:   view.HttpMethod,
+		Delay:        view.Delay,
+		Distribution: view.Distribution,
+		Min:          view.Min,
+		Max:          view.Max,
+		Mean:         view.Mean,
+		StdDev:       view.StdDev,
+		Median:       view.Median,
+		P99:          view.P99,
+	}
+}
+
+// NewDelayFromView converts the delay attached to a response
+func NewDelayFromView(view interfaces.Delay) *ResponseDelay {
+	if view == nil {
+		return nil
+	}
+
+	return &ResponseDelay{
+		Delay:        view.GetDelay(),
+		Distribution: view.GetDistribution(),
+		Min:          view.GetMin(),
+		Max:          view.GetMax(),
+		Mean:         view.GetMean(),
+		StdDev:       view.GetStdDev(),
+		Median:       view.GetMedian(),
+		P99:          view.GetP99(),
+	}
+}
+
+func (this *ResponseDelay) ConvertToDelayView() *v1.DelayView {
+	if this == nil {
+		return nil
+	}
+
+	return &v1.DelayView{
+		Delay:        this.Delay,
+		Distribution: this.Distribution,
+		Min:          this.Min,
+		Max:          this.Max,
+		Mean:         this.Mean,
+		StdDev:       this.StdDev,
+		Median:       this.Median,
+		P99:          this.P99,
+	}
+}
+
+// Validate checks a delay attached to a response, which needs no pattern
+func (this *ResponseDelay) Validate() error {
+	if !hasDelayValues(*this) {
+		return errors.New(fmt.Sprintf("Config error - Missing values found in: %v", *this))
+	}
+	return validateDelayDistribution(*this)
+}
+
 func ValidateResponseDelayPayload(j v1.ResponseDelayPayloadView) (err error) {
 	if j.Data != nil {
-		for _, delay := range j.Data {
-			if delay.UrlPattern != "" && delay.Delay != 0 {
+		for _, view := range j.Data {
+			delay := NewResponseDelayFromView(view)
+			if (delay.UrlPattern != "" || view.Request != nil) && hasDelayValues(delay) {
 				if _, err := regexp.Compile(delay.UrlPattern); err != nil {
 					return errors.New(fmt.Sprintf("Response delay entry skipped due to invalid pattern : %s", delay.UrlPattern))
 				}
 			} else {
-				return errors.New(fmt.Sprintf("Config error - Missing values found in: %v", delay))
+				return errors.New(fmt.Sprintf("Config error - Missing values found in: %v", view))
+			}
+
+			if err := validateDelayDistribution(delay); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
+func hasDelayValues(delay ResponseDelay) bool {
+	switch delay.Distribution {
+	case "", DelayDistributionFixed:
+		return delay.Delay != 0
+	case DelayDistributionUniform:
+		return delay.Max != 0
+	case DelayDistributionNormal:
+		return delay.Mean != 0
+	case DelayDistributionLogNormal:
+		return delay.Median != 0 && delay.P99 != 0
+	}
+	return true
+}
+
+func validateDelayDistribution(delay ResponseDelay) error {
+	switch delay.Distribution {
+	case "", DelayDistributionFixed:
+		return nil
+	case DelayDistributionUniform:
+		if delay.Min < 0 || delay.Max < delay.Min {
+			return fmt.Errorf("Config error - uniform delay for %s needs 0 <= min <= max", delay.UrlPattern)
+		}
+	case DelayDistributionNormal:
+		if delay.Mean < 0 || delay.StdDev < 0 {
+			return fmt.Errorf("Config error - normal delay for %s needs a positive mean and stdDev", delay.UrlPattern)
+		}
+	case DelayDistributionLogNormal:
+		if delay.Median <= 0 || delay.P99 < delay.Median {
+			return fmt.Errorf("Config error - lognormal delay for %s needs 0 < median <= p99", delay.UrlPattern)
+		}
+	default:
+		return fmt.Errorf("Config error - unknown delay distribution: %s", delay.Distribution)
+	}
+
+	if delay.Max != 0 && delay.Max < delay.Min {
+		return fmt.Errorf("Config error - delay for %s has max less than min", delay.UrlPattern)
+	}
+
+	return nil
+}
+
+// Duration returns the delay to apply, sampling the distribution if there is one
+func (this *ResponseDelay) Duration() time.Duration {
+	var delay float64
+
+	switch this.Distribution {
+	case DelayDistributionUniform:
+		delay = float64(this.Min) + rand.Float64()*float64(this.Max-this.Min)
+	case DelayDistributionNormal:
+		delay = this.bound(rand.NormFloat64()*float64(this.StdDev) + float64(this.Mean))
+	case DelayDistributionLogNormal:
+		mu := math.Log(float64(this.Median))
+		sigma := (math.Log(float64(this.P99)) - mu) / p99ZScore
+		delay = this.bound(math.Exp(rand.NormFloat64()*sigma + mu))
+	default:
+		delay = float64(this.Delay)
+	}
+
+	return time.Duration(delay * float64(time.Millisecond))
+}
+
+func (this *ResponseDelay) bound(delay float64) float64 {
+	if delay < float64(this.Min) {
+		delay = float64(this.Min)
+	}
+	if this.Max != 0 && delay > float64(this.Max) {
+		delay = float64(this.Max)
+	}
+	return delay
+}
+
 func (this *ResponseDelay) Execute() {
 	// apply the delay - must be called from goroutine handling the request
-	log.Info("Pausing before sending the response to simulate delays")
-	time.Sleep(time.Duration(this.Delay) * time.Millisecond)
+	delay := this.Duration()
+	log.WithFields(log.Fields{
+		"delay":        delay.String(),
+		"distribution": this.Distribution,
+	}).Info("Pausing before sending the response to simulate delays")
+	time.Sleep(delay)
 	log.Info("Response delay completed")
 }
 
+// Matches checks the url pattern and method of the delay against a request
+func (this ResponseDelay) Matches(request RequestDetails) bool {
+	match := regexp.MustCompile(this.UrlPattern).MatchString(request.Destination + request.Path)
+	return match && (this.HttpMethod == "" || strings.EqualFold(this.HttpMethod, request.Method))
+}
+
 func (this *ResponseDelayList) GetDelay(request RequestDetails) *ResponseDelay {
 	for _, val := range *this {
-		match := regexp.MustCompile(val.UrlPattern).MatchString(request.Destination + request.Path)
-		if match {
-			if val.HttpMethod == "" || strings.EqualFold(val.HttpMethod, request.Method) {
-				log.Info("Found response delay setting for this request host: ", val)
-				return &val
-			}
+		if val.Matches(request) {
+			log.Info("Found response delay setting for this request host: ", val)
+			return &val
 		}
 	}
 	return nil
@@ -65,18 +229,27 @@ func (this ResponseDelayList) ConvertToResponseDelayPayloadView() v1.ResponseDel
 	}
 
 	for _, responseDelay := range this {
-		responseDelayView := v1.ResponseDelayView{
-			UrlPattern: responseDelay.UrlPattern,
-			HttpMethod: responseDelay.HttpMethod,
-			Delay:      responseDelay.Delay,
-		}
-
-		payloadView.Data = append(payloadView.Data, responseDelayView)
+		payloadView.Data = append(payloadView.Data, responseDelay.ConvertToResponseDelayView())
 	}
 
 	return payloadView
 }
 
+func (this ResponseDelay) ConvertToResponseDelayView() v1.ResponseDelayView {
+	return v1.ResponseDelayView{
+		UrlPattern:   this.UrlPattern,
+		HttpMethod:   this.HttpMethod,
+		Delay:        this.Delay,
+		Distribution: this.Distribution,
+		Min:          this.Min,
+		Max:          this.Max,
+		Mean:         this.Mean,
+		StdDev:       this.StdDev,
+		Median:       this.Median,
+		P99:          this.P99,
+	}
+}
+
 func (this *ResponseDelayList) Len() int {
 	list := []ResponseDelay{}
 	if this != nil {
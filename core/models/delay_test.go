@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	. "github.com/onsi/gomega"
+	"sort"
 	"testing"
+	"time"
 )
 
 func TestConvertJsonStringToResponseDelayConfig(t *testing.T) {
@@ -204,3 +206,131 @@ func TestResponseDelayList_ConvertToPayloadView(t *testing.T) {
 	Expect(payloadView.Data[0].Delay).To(Equal(100))
 
 }
+
+func TestValidateResponseDelayPayload_AcceptsDistributions(t *testing.T) {
+	RegisterTestingT(t)
+
+	err := ValidateResponseDelayPayload(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{UrlPattern: ".", Distribution: "uniform", Min: 100, Max: 200},
+			{UrlPattern: ".", Distribution: "normal", Mean: 100, StdDev: 20},
+			{UrlPattern: ".", Distribution: "lognormal", Median: 100, P99: 900, Max: 2000},
+		},
+	})
+	Expect(err).To(BeNil())
+}
+
+func TestValidateResponseDelayPayload_RejectsInvalidDistributions(t *testing.T) {
+	RegisterTestingT(t)
+
+	invalid := []v1.ResponseDelayView{
+		{UrlPattern: ".", Distribution: "uniform", Min: 200},
+		{UrlPattern: ".", Distribution: "uniform", Min: 300, Max: 200},
+		{UrlPattern: ".", Distribution: "normal", StdDev: 20},
+		{UrlPattern: ".", Distribution: "normal", Mean: 100, StdDev: -1},
+		{UrlPattern: ".", Distribution: "lognormal", Median: 100},
+		{UrlPattern: ".", Distribution: "lognormal", Median: 900, P99: 100},
+		{UrlPattern: ".", Distribution: "poisson", Delay: 100},
+	}
+
+	for _, delay := range invalid {
+		err := ValidateResponseDelayPayload(v1.ResponseDelayPayloadView{Data: []v1.ResponseDelayView{delay}})
+		Expect(err).ToNot(BeNil(), "%v", delay)
+	}
+}
+
+func TestResponseDelay_Duration_ReturnsTheFixedDelay(t *testing.T) {
+	RegisterTestingT(t)
+
+	delay := ResponseDelay{Delay: 100}
+
+	Expect(delay.Duration()).To(Equal(100 * time.Millisecond))
+}
+
+func TestResponseDelay_Duration_SamplesBetweenMinAndMaxForUniform(t *testing.T) {
+	RegisterTestingT(t)
+
+	delay := ResponseDelay{Distribution: "uniform", Min: 100, Max: 200}
+
+	for i := 0; i < 1000; i++ {
+		Expect(delay.Duration()).To(BeNumerically(">=", 100*time.Millisecond))
+		Expect(delay.Duration()).To(BeNumerically("<=", 200*time.Millisecond))
+	}
+}
+
+func TestResponseDelay_Duration_BoundsNormalSamples(t *testing.T) {
+	RegisterTestingT(t)
+
+	delay := ResponseDelay{Distribution: "normal", Mean: 100, StdDev: 1000, Max: 300}
+
+	for i := 0; i < 1000; i++ {
+		Expect(delay.Duration()).To(BeNumerically(">=", 0))
+		Expect(delay.Duration()).To(BeNumerically("<=", 300*time.Millisecond))
+	}
+}
+
+func TestResponseDelay_Duration_SamplesLogNormalAroundTheMedian(t *testing.T) {
+	RegisterTestingT(t)
+
+	delay := ResponseDelay{Distribution: "lognormal", Median: 100, P99: 1000}
+
+	samples := make([]time.Duration, 5000)
+	for i := range samples {
+		samples[i] = delay.Duration()
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	Expect(samples[len(samples)/2]).To(BeNumerically("~", 100*time.Millisecond, 15*time.Millisecond))
+	Expect(samples[len(samples)*99/100]).To(BeNumerically("~", 1000*time.Millisecond, 300*time.Millisecond))
+}
+
+func TestResponseDelayList_ConvertToPayloadView_IncludesDistributions(t *testing.T) {
+	RegisterTestingT(t)
+
+	delays := ResponseDelayList{
+		{UrlPattern: ".", Distribution: "lognormal", Median: 100, P99: 900, Max: 2000},
+	}
+
+	payloadView := delays.ConvertToResponseDelayPayloadView()
+
+	Expect(payloadView.Data[0]).To(Equal(v1.ResponseDelayView{
+		UrlPattern:   ".",
+		Distribution: "lognormal",
+		Median:       100,
+		P99:          900,
+		Max:          2000,
+	}))
+}
+
+func TestValidateResponseDelayPayload_AcceptsARequestInsteadOfAUrlPattern(t *testing.T) {
+	RegisterTestingT(t)
+
+	err := ValidateResponseDelayPayload(v1.ResponseDelayPayloadView{
+		Data: []v1.ResponseDelayView{
+			{
+				Delay:   100,
+				Request: &v1.RequestDetailsView{Headers: map[string][]string{"X-Tenant": []string{"big"}}},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+}
+
+func TestResponseDelay_Validate_ChecksDelaysAttachedToResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect((&ResponseDelay{Delay: 100}).Validate()).To(BeNil())
+	Expect((&ResponseDelay{Distribution: "uniform", Min: 10, Max: 20}).Validate()).To(BeNil())
+
+	Expect((&ResponseDelay{}).Validate()).ToNot(BeNil())
+	Expect((&ResponseDelay{Distribution: "lognormal", Median: 100, P99: 10}).Validate()).ToNot(BeNil())
+}
+
+func TestNewDelayFromView_ConvertsBackToTheSameView(t *testing.T) {
+	RegisterTestingT(t)
+
+	view := &v1.DelayView{Distribution: "normal", Mean: 100, StdDev: 10, Max: 300}
+
+	Expect(NewDelayFromView(view).ConvertToDelayView()).To(Equal(view))
+	Expect(NewDelayFromView(nil)).To(BeNil())
+}
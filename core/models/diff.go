@@ -0,0 +1,221 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// Headers which differ on every response, so are left out of a diff
+var volatileHeaders = map[string]bool{
+	"Date":           true,
+	"Content-Length": true,
+}
+
+// Difference is a single part of a response which has changed from the simulation
+type Difference struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// ResponseDiff is every difference between a real response and the simulated
+// response for the same request
+type ResponseDiff struct {
+	Request     RequestDetails
+	Time        time.Time
+	Differences []Difference
+}
+
+func (this ResponseDiff) ConvertToResponseDiffView() v2.ResponseDiffView {
+	differences := []v2.DifferenceView{}
+	for _, difference := range this.Differences {
+		differences = append(differences, v2.DifferenceView{
+			Field:    difference.Field,
+			Expected: difference.Expected,
+			Actual:   difference.Actual,
+		})
+	}
+
+	return v2.ResponseDiffView{
+		Request:     this.Request.ConvertToRequestDetailsView(),
+		Time:        this.Time.Format(time.RFC3339),
+		Differences: differences,
+	}
+}
+
+// MaxResponseDiffs is how many diffs are kept, older ones are dropped first
+const MaxResponseDiffs = 1000
+
+// ResponseDiffList holds the latest diffs found in diff mode. It is safe to use
+// from several requests at once.
+type ResponseDiffList struct {
+	mutex sync.Mutex
+	diffs []ResponseDiff
+}
+
+func (this *ResponseDiffList) Add(diff ResponseDiff) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.diffs = append(this.diffs, diff)
+	if dropped := len(this.diffs) - MaxResponseDiffs; dropped > 0 {
+		this.diffs = append([]ResponseDiff{}, this.diffs[dropped:]...)
+	}
+}
+
+func (this *ResponseDiffList) GetAll() []ResponseDiff {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return append([]ResponseDiff{}, this.diffs...)
+}
+
+func (this *ResponseDiffList) Clear() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.diffs = nil
+}
+
+// DiffResponses compares the status, headers and body of a real response with
+// the simulated one. JSON bodies are compared field by field.
+func DiffResponses(expected, actual ResponseDetails) []Difference {
+	differences := DiffStatusAndHeaders(expected, actual)
+
+	var expectedJSON, actualJSON interface{}
+	if json.Unmarshal([]byte(expected.Body), &expectedJSON) == nil && json.Unmarshal([]byte(actual.Body), &actualJSON) == nil {
+		differences = append(differences, diffJSON("body", expectedJSON, actualJSON)...)
+	} else if expected.Body != actual.Body {
+		differences = append(differences, Difference{
+			Field:    "body",
+			Expected: expected.Body,
+			Actual:   actual.Body,
+		})
+	}
+
+	return differences
+}
+
+// DiffStatusAndHeaders compares the status and headers of a real response with
+// the simulated one, for responses such as event streams whose body never ends
+func DiffStatusAndHeaders(expected, actual ResponseDetails) []Difference {
+	var differences []Difference
+
+	if expected.Status != actual.Status {
+		differences = append(differences, Difference{
+			Field:    "status",
+			Expected: fmt.Sprint(expected.Status),
+			Actual:   fmt.Sprint(actual.Status),
+		})
+	}
+
+	return append(differences, diffHeaders(expected.Headers, actual.Headers)...)
+}
+
+func diffHeaders(expected, actual map[string][]string) []Difference {
+	expectedValues := canonicalHeaderValues(expected)
+	actualValues := canonicalHeaderValues(actual)
+
+	var names []string
+	for name := range expectedValues {
+		names = append(names, name)
+	}
+	for name := range actualValues {
+		if _, ok := expectedValues[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var differences []Difference
+	for _, name := range names {
+		if volatileHeaders[name] || expectedValues[name] == actualValues[name] {
+			continue
+		}
+		differences = append(differences, Difference{
+			Field:    "headers." + name,
+			Expected: expectedValues[name],
+			Actual:   actualValues[name],
+		})
+	}
+	return differences
+}
+
+func canonicalHeaderValues(headers map[string][]string) map[string]string {
+	values := map[string]string{}
+	for name, value := range headers {
+		key := http.CanonicalHeaderKey(name)
+		if values[key] != "" {
+			values[key] += ", "
+		}
+		values[key] += strings.Join(value, ", ")
+	}
+	return values
+}
+
+// diffJSON walks two decoded JSON values, listing each field which differs by
+// its path and its value as JSON, or an empty string where it is missing
+func diffJSON(path string, expected, actual interface{}) []Difference {
+	expectedObject, expectedIsObject := expected.(map[string]interface{})
+	actualObject, actualIsObject := actual.(map[string]interface{})
+	if expectedIsObject && actualIsObject {
+		var keys []string
+		for key := range expectedObject {
+			keys = append(keys, key)
+		}
+		for key := range actualObject {
+			if _, ok := expectedObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		var differences []Difference
+		for _, key := range keys {
+			differences = append(differences, diffJSON(path+"."+key, expectedObject[key], actualObject[key])...)
+		}
+		return differences
+	}
+
+	expectedArray, expectedIsArray := expected.([]interface{})
+	actualArray, actualIsArray := actual.([]interface{})
+	if expectedIsArray && actualIsArray {
+		var differences []Difference
+		for i := 0; i < len(expectedArray) || i < len(actualArray); i++ {
+			var expectedItem, actualItem interface{}
+			if i < len(expectedArray) {
+				expectedItem = expectedArray[i]
+			}
+			if i < len(actualArray) {
+				actualItem = actualArray[i]
+			}
+			differences = append(differences, diffJSON(fmt.Sprintf("%s[%d]", path, i), expectedItem, actualItem)...)
+		}
+		return differences
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+		return nil
+	}
+	return []Difference{{
+		Field:    path,
+		Expected: jsonValue(expected),
+		Actual:   jsonValue(actual),
+	}}
+}
+
+func jsonValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffResponses_FindsNoDifferencesInTheSameResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status:  200,
+		Body:    `{"name": "hoverfly"}`,
+		Headers: map[string][]string{"Content-Type": []string{"application/json"}},
+	}
+
+	Expect(DiffResponses(response, response)).To(BeEmpty())
+}
+
+func TestDiffResponses_ComparesStatusHeadersAndBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	expected := ResponseDetails{
+		Status: 200,
+		Body:   "hello",
+		Headers: map[string][]string{
+			"Content-Type": []string{"text/plain"},
+			"Date":         []string{"yesterday"},
+		},
+	}
+	actual := ResponseDetails{
+		Status: 201,
+		Body:   "goodbye",
+		Headers: map[string][]string{
+			"content-type": []string{"text/html"},
+			"Date":         []string{"today"},
+			"X-New":        []string{"new"},
+		},
+	}
+
+	Expect(DiffResponses(expected, actual)).To(Equal([]Difference{
+		{Field: "status", Expected: "200", Actual: "201"},
+		{Field: "headers.Content-Type", Expected: "text/plain", Actual: "text/html"},
+		{Field: "headers.X-New", Expected: "", Actual: "new"},
+		{Field: "body", Expected: "hello", Actual: "goodbye"},
+	}))
+}
+
+func TestDiffResponses_ComparesJSONBodiesFieldByField(t *testing.T) {
+	RegisterTestingT(t)
+
+	expected := ResponseDetails{
+		Status: 200,
+		Body:   `{"id": 1, "tags": ["a", "b"], "owner": {"name": "ben"}, "old": true}`,
+	}
+	actual := ResponseDetails{
+		Status: 200,
+		Body:   `{"owner": {"name": "tom"}, "id": 1, "tags": ["a"]}`,
+	}
+
+	Expect(DiffResponses(expected, actual)).To(Equal([]Difference{
+		{Field: "body.old", Expected: "true", Actual: ""},
+		{Field: "body.owner.name", Expected: `"ben"`, Actual: `"tom"`},
+		{Field: "body.tags[1]", Expected: `"b"`, Actual: ""},
+	}))
+}
+
+func TestResponseDiffList_KeepsOnlyTheLatestDiffs(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &ResponseDiffList{}
+	for i := 0; i < MaxResponseDiffs+10; i++ {
+		unit.Add(ResponseDiff{Request: RequestDetails{Path: fmt.Sprintf("/%d", i)}})
+	}
+
+	diffs := unit.GetAll()
+	Expect(diffs).To(HaveLen(MaxResponseDiffs))
+	Expect(diffs[0].Request.Path).To(Equal("/10"))
+	Expect(diffs[MaxResponseDiffs-1].Request.Path).To(Equal(fmt.Sprintf("/%d", MaxResponseDiffs+9)))
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings which are decoded on capture and re-encoded on playback
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+)
+
+// ContentCoding returns the coding of a body when it is one Hoverfly can decode
+func ContentCoding(header http.Header) string {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding"))) {
+	case EncodingGzip, "x-gzip":
+		return EncodingGzip
+	case EncodingDeflate:
+		return EncodingDeflate
+	case EncodingBrotli:
+		return EncodingBrotli
+	}
+	return ""
+}
+
+// DecodeContent decodes a body by its Content-Encoding, returning the coding it was
+// decoded from, or the body as it was with no coding when it cannot be decoded
+func DecodeContent(header http.Header, body []byte) ([]byte, string) {
+	coding := ContentCoding(header)
+	if coding == "" || len(body) == 0 {
+		return body, ""
+	}
+
+	decoded, err := DecodeBody(body, coding)
+	if err != nil {
+		return body, ""
+	}
+	return decoded, coding
+}
+
+func DecodeBody(body []byte, coding string) ([]byte, error) {
+	var reader io.Reader
+	switch coding {
+	case EncodingGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reader = gzipReader
+	case EncodingDeflate:
+		// deflate is meant to be zlib wrapped, though some servers send it raw
+		zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader = flate.NewReader(bytes.NewReader(body))
+		} else {
+			reader = zlibReader
+		}
+	case EncodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("Unsupported content encoding: %s", coding)
+	}
+	return ioutil.ReadAll(reader)
+}
+
+func EncodeBody(body []byte, coding string) ([]byte, error) {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	switch coding {
+	case EncodingGzip:
+		writer = gzip.NewWriter(&buffer)
+	case EncodingDeflate:
+		writer = zlib.NewWriter(&buffer)
+	case EncodingBrotli:
+		writer = brotli.NewWriter(&buffer)
+	default:
+		return nil, fmt.Errorf("Unsupported content encoding: %s", coding)
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"bytes"
+	"compress/flate"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	. "github.com/onsi/gomega"
+)
+
+func TestEncodeBody_CanBeDecodedAgain(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, coding := range []string{EncodingGzip, EncodingDeflate, EncodingBrotli} {
+		encoded, err := EncodeBody([]byte("hello world"), coding)
+		Expect(err).To(BeNil())
+		Expect(encoded).ToNot(Equal([]byte("hello world")))
+
+		decoded, err := DecodeBody(encoded, coding)
+		Expect(err).To(BeNil())
+		Expect(string(decoded)).To(Equal("hello world"))
+	}
+}
+
+func TestDecodeBody_AcceptsRawDeflate(t *testing.T) {
+	RegisterTestingT(t)
+
+	var buffer bytes.Buffer
+	writer, _ := flate.NewWriter(&buffer, flate.DefaultCompression)
+	writer.Write([]byte("hello world"))
+	writer.Close()
+
+	decoded, err := DecodeBody(buffer.Bytes(), EncodingDeflate)
+	Expect(err).To(BeNil())
+	Expect(string(decoded)).To(Equal("hello world"))
+}
+
+func TestDecodeContent_LeavesBodiesItCannotDecode(t *testing.T) {
+	RegisterTestingT(t)
+
+	body, coding := DecodeContent(http.Header{"Content-Encoding": []string{"compress"}}, []byte("compressed"))
+	Expect(string(body)).To(Equal("compressed"))
+	Expect(coding).To(Equal(""))
+
+	body, coding = DecodeContent(http.Header{"Content-Encoding": []string{"gzip"}}, []byte("not gzip"))
+	Expect(string(body)).To(Equal("not gzip"))
+	Expect(coding).To(Equal(""))
+
+	encoded, _ := EncodeBody([]byte("hello"), EncodingGzip)
+	body, coding = DecodeContent(http.Header{"Content-Encoding": []string{"x-gzip"}}, encoded)
+	Expect(string(body)).To(Equal("hello"))
+	Expect(coding).To(Equal(EncodingGzip))
+}
+
+func TestDecodeContent_DecodesBrotli(t *testing.T) {
+	RegisterTestingT(t)
+
+	var buffer bytes.Buffer
+	writer := brotli.NewWriterLevel(&buffer, brotli.BestCompression)
+	writer.Write([]byte(`{"name": "hoverfly"}`))
+	writer.Close()
+
+	body, coding := DecodeContent(http.Header{"Content-Encoding": []string{"br"}}, buffer.Bytes())
+	Expect(string(body)).To(Equal(`{"name": "hoverfly"}`))
+	Expect(coding).To(Equal(EncodingBrotli))
+}
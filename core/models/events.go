@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/base64"
+	"io"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
+)
+
+// ResponseEvent is a piece of a streamed response, such as a server-sent event,
+// which arrived Time milliseconds after the response started
+type ResponseEvent struct {
+	Time int    `json:"time"`
+	Data string `json:"data"`
+}
+
+// NewResponseEventsFromView converts events, decoding their data when the body is encoded
+func NewResponseEventsFromView(views []interfaces.ResponseEvent, encoded bool) []ResponseEvent {
+	if len(views) == 0 {
+		return nil
+	}
+
+	events := make([]ResponseEvent, len(views))
+	for i, view := range views {
+		data := view.GetData()
+		if encoded {
+			decoded, _ := base64.StdEncoding.DecodeString(data)
+			data = string(decoded)
+		}
+		events[i] = ResponseEvent{Time: view.GetTime(), Data: data}
+	}
+	return events
+}
+
+func convertToResponseEventViews(events []ResponseEvent, encode bool) []v1.ResponseEventView {
+	if len(events) == 0 {
+		return nil
+	}
+
+	views := make([]v1.ResponseEventView, len(events))
+	for i, event := range events {
+		data := event.Data
+		if encode {
+			data = base64.StdEncoding.EncodeToString([]byte(data))
+		}
+		views[i] = v1.ResponseEventView{Time: event.Time, Data: data}
+	}
+	return views
+}
+
+// EventStreamBody replays recorded events with their original spacing, divided by
+// the speed factor
+type EventStreamBody struct {
+	events  []ResponseEvent
+	speed   float64
+	start   time.Time
+	next    int
+	pending []byte
+	sleep   func(time.Duration)
+}
+
+func NewEventStreamBody(events []ResponseEvent, speed float64) *EventStreamBody {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	return &EventStreamBody{
+		events: events,
+		speed:  speed,
+		sleep:  time.Sleep,
+	}
+}
+
+func (this *EventStreamBody) Read(p []byte) (int, error) {
+	if len(this.pending) == 0 {
+		if this.next >= len(this.events) {
+			return 0, io.EOF
+		}
+
+		if this.start.IsZero() {
+			this.start = time.Now()
+		}
+
+		event := this.events[this.next]
+		this.next++
+
+		due := time.Duration(float64(event.Time) / this.speed * float64(time.Millisecond))
+		if wait := due - time.Since(this.start); wait > 0 {
+			this.sleep(wait)
+		}
+
+		this.pending = []byte(event.Data)
+	}
+
+	n := copy(p, this.pending)
+	this.pending = this.pending[n:]
+	return n, nil
+}
+
+func (this *EventStreamBody) Close() error { return nil }
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestEventStreamBody_ReplaysEventsWithTheirSpacing(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sleeps []time.Duration
+	body := NewEventStreamBody([]ResponseEvent{
+		{Time: 0, Data: "data: one\n\n"},
+		{Time: 200, Data: "data: two\n\n"},
+	}, 1)
+	body.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	data, err := ioutil.ReadAll(body)
+
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("data: one\n\ndata: two\n\n"))
+	Expect(sleeps).To(HaveLen(1))
+	Expect(sleeps[0]).To(BeNumerically("~", 200*time.Millisecond, 20*time.Millisecond))
+}
+
+func TestEventStreamBody_CompressesTheSpacingWithTheSpeedFactor(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sleeps []time.Duration
+	body := NewEventStreamBody([]ResponseEvent{
+		{Time: 0, Data: "a"},
+		{Time: 1000, Data: "b"},
+	}, 10)
+	body.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	ioutil.ReadAll(body)
+
+	Expect(sleeps).To(HaveLen(1))
+	Expect(sleeps[0]).To(BeNumerically("~", 100*time.Millisecond, 20*time.Millisecond))
+}
+
+func TestResponseDetails_ConvertToResponseDetailsView_KeepsEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status:  200,
+		Body:    "data: one\n\n",
+		Headers: map[string][]string{"Content-Type": []string{"text/event-stream"}},
+		Events:  []ResponseEvent{{Time: 10, Data: "data: one\n\n"}},
+	}
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.Events).To(HaveLen(1))
+	Expect(view.Events[0].Time).To(Equal(10))
+
+	Expect(NewResponseDetailsFromResponse(view).Events).To(Equal(response.Events))
+}
+
+func TestNewResponseDetailsFromResponse_DecodesEncodedEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status:  200,
+		Body:    "\x00\x01",
+		Headers: map[string][]string{"Content-Encoding": []string{"gzip"}},
+		Events:  []ResponseEvent{{Time: 0, Data: "\x00"}, {Time: 5, Data: "\x01"}},
+	}
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.EncodedBody).To(BeTrue())
+	Expect(view.Events[0].Data).To(Equal("AA=="))
+
+	Expect(NewResponseDetailsFromResponse(view).Events).To(Equal(response.Events))
+	Expect(NewResponseDetailsFromResponse(v2.ResponseDetailsView{}).Events).To(BeNil())
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
+)
+
+const (
+	// FaultClose closes the connection without sending a response
+	FaultClose = "close"
+	// FaultReset resets the TCP connection without sending a response
+	FaultReset = "reset"
+	// FaultHang never responds, holding the connection until the client gives up
+	FaultHang = "hang"
+	// FaultTruncate sends the headers and only half of the body
+	FaultTruncate = "truncate"
+	// FaultGarbage sends bytes that are not HTTP and closes the connection
+	FaultGarbage = "garbage"
+	// FaultStatus replaces the response with an error status
+	FaultStatus = "status"
+)
+
+// Fault describes a failure to inject instead of the response. Percentage is
+// the chance of the fault happening on a request, when it is not set the fault
+// always happens.
+type Fault struct {
+	Type       string `json:"type"`
+	Percentage *int   `json:"percentage,omitempty"`
+	Status     int    `json:"status,omitempty"`
+}
+
+func NewFaultFromView(view interfaces.Fault) *Fault {
+	if view == nil {
+		return nil
+	}
+
+	return &Fault{
+		Type:       view.GetType(),
+		Percentage: view.GetPercentage(),
+		Status:     view.GetStatus(),
+	}
+}
+
+func (this *Fault) ConvertToFaultView() *v1.FaultView {
+	if this == nil {
+		return nil
+	}
+
+	return &v1.FaultView{
+		Type:       this.Type,
+		Percentage: this.Percentage,
+		Status:     this.Status,
+	}
+}
+
+func (this *Fault) Validate() error {
+	switch this.Type {
+	case FaultClose, FaultReset, FaultHang, FaultTruncate, FaultGarbage:
+	case FaultStatus:
+		if this.Status != 0 && (this.Status < 100 || this.Status > 999) {
+			return fmt.Errorf("Invalid status for fault: %d", this.Status)
+		}
+	default:
+		return fmt.Errorf("Unknown fault type: %s", this.Type)
+	}
+
+	if this.Percentage != nil && (*this.Percentage < 0 || *this.Percentage > 100) {
+		return fmt.Errorf("Fault percentage must be between 0 and 100, got %d", *this.Percentage)
+	}
+
+	return nil
+}
+
+// Triggered rolls the percentage of the fault to decide whether it happens on this request
+func (this *Fault) Triggered() bool {
+	if this == nil {
+		return false
+	}
+
+	return this.Percentage == nil || rand.Intn(100) < *this.Percentage
+}
+
+// GetStatus returns the status of a status fault, 503 when none is set
+func (this *Fault) GetStatus() int {
+	if this.Status == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return this.Status
+}
+
+type ResponseFault struct {
+	UrlPattern string
+	HttpMethod string
+	Fault      Fault
+}
+
+type ResponseFaultList []ResponseFault
+
+func NewResponseFaultListFromView(views []v2.ResponseFaultView) (ResponseFaultList, error) {
+	faults := ResponseFaultList{}
+
+	for _, view := range views {
+		if _, err := regexp.Compile(view.UrlPattern); err != nil {
+			return nil, fmt.Errorf("Response fault has an invalid pattern: %s", view.UrlPattern)
+		}
+
+		fault := ResponseFault{
+			UrlPattern: view.UrlPattern,
+			HttpMethod: view.HttpMethod,
+			Fault: Fault{
+				Type:       view.Type,
+				Percentage: view.Percentage,
+				Status:     view.Status,
+			},
+		}
+
+		if err := fault.Fault.Validate(); err != nil {
+			return nil, err
+		}
+
+		faults = append(faults, fault)
+	}
+
+	return faults, nil
+}
+
+// GetFault returns the fault of the first entry matching the request
+func (this ResponseFaultList) GetFault(request RequestDetails) *Fault {
+	for _, val := range this {
+		match := regexp.MustCompile(val.UrlPattern).MatchString(request.Destination + request.Path)
+		if match && (val.HttpMethod == "" || strings.EqualFold(val.HttpMethod, request.Method)) {
+			log.Info("Found response fault setting for this request host: ", val)
+			fault := val.Fault
+			return &fault
+		}
+	}
+	return nil
+}
+
+func (this ResponseFaultList) ConvertToResponseFaultViews() []v2.ResponseFaultView {
+	views := []v2.ResponseFaultView{}
+
+	for _, fault := range this {
+		views = append(views, v2.ResponseFaultView{
+			UrlPattern: fault.UrlPattern,
+			HttpMethod: fault.HttpMethod,
+			Type:       fault.Fault.Type,
+			Percentage: fault.Fault.Percentage,
+			Status:     fault.Fault.Status,
+		})
+	}
+
+	return views
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func TestFault_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, faultType := range []string{"close", "reset", "hang", "truncate", "garbage", "status"} {
+		Expect((&Fault{Type: faultType}).Validate()).To(BeNil())
+	}
+
+	Expect((&Fault{Type: "explode"}).Validate()).ToNot(BeNil())
+	Expect((&Fault{Type: "status", Status: 42}).Validate()).ToNot(BeNil())
+	Expect((&Fault{Type: "close", Percentage: util.IntToPointer(101)}).Validate()).ToNot(BeNil())
+}
+
+func TestFault_Triggered(t *testing.T) {
+	RegisterTestingT(t)
+
+	var noFault *Fault
+	Expect(noFault.Triggered()).To(BeFalse())
+
+	Expect((&Fault{Type: "close"}).Triggered()).To(BeTrue())
+	Expect((&Fault{Type: "close", Percentage: util.IntToPointer(100)}).Triggered()).To(BeTrue())
+	Expect((&Fault{Type: "close", Percentage: util.IntToPointer(0)}).Triggered()).To(BeFalse())
+}
+
+func TestFault_GetStatus_DefaultsToServiceUnavailable(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect((&Fault{Type: "status"}).GetStatus()).To(Equal(503))
+	Expect((&Fault{Type: "status", Status: 500}).GetStatus()).To(Equal(500))
+}
+
+func TestResponseFaultList_GetFault(t *testing.T) {
+	RegisterTestingT(t)
+
+	faults, err := NewResponseFaultListFromView([]v2.ResponseFaultView{
+		{UrlPattern: "example.com/slow", HttpMethod: "GET", Type: "hang"},
+		{UrlPattern: "example.com", Type: "reset", Percentage: util.IntToPointer(10)},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(*faults.GetFault(RequestDetails{Destination: "example.com", Path: "/slow", Method: "GET"})).To(Equal(Fault{Type: "hang"}))
+	Expect(*faults.GetFault(RequestDetails{Destination: "example.com", Path: "/slow", Method: "POST"})).To(Equal(Fault{Type: "reset", Percentage: util.IntToPointer(10)}))
+	Expect(faults.GetFault(RequestDetails{Destination: "other.com", Path: "/", Method: "GET"})).To(BeNil())
+
+	Expect(faults.ConvertToResponseFaultViews()).To(HaveLen(2))
+}
+
+func TestNewResponseFaultListFromView_ReturnsAnErrorForInvalidFaults(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewResponseFaultListFromView([]v2.ResponseFaultView{{UrlPattern: "(", Type: "close"}})
+	Expect(err).ToNot(BeNil())
+
+	_, err = NewResponseFaultListFromView([]v2.ResponseFaultView{{UrlPattern: ".", Type: "explode"}})
+	Expect(err).ToNot(BeNil())
+}
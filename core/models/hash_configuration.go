@@ -0,0 +1,268 @@
+package models
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// HashConfiguration changes which parts of a request make up the key that recorded
+// request response pairs are stored and looked up with. A nil HashConfiguration
+// hashes requests the same way as RequestDetails.Hash.
+type HashConfiguration struct {
+	// IncludeQueryParams - when set, only these query parameters are hashed
+	IncludeQueryParams []string
+	// ExcludeQueryParams - query parameters which are never hashed, such as cache busters
+	ExcludeQueryParams []string
+	// IncludeHeaders - headers which are hashed, by default no headers are
+	IncludeHeaders []string
+	// ExcludeBody - when true, the body is not hashed at all
+	ExcludeBody bool
+	// ExcludeBodyPaths - JSON paths, such as $.meta.timestamp, which are removed from JSON bodies before hashing
+	ExcludeBodyPaths []string
+}
+
+func NewHashConfigurationFromView(view v2.HashConfigurationView) (*HashConfiguration, error) {
+	for _, path := range view.ExcludeBodyPaths {
+		if _, err := parseBodyPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return &HashConfiguration{
+		IncludeQueryParams: view.IncludeQueryParams,
+		ExcludeQueryParams: view.ExcludeQueryParams,
+		IncludeHeaders:     view.IncludeHeaders,
+		ExcludeBody:        view.ExcludeBody,
+		ExcludeBodyPaths:   view.ExcludeBodyPaths,
+	}, nil
+}
+
+func (this *HashConfiguration) ConvertToHashConfigurationView() v2.HashConfigurationView {
+	if this == nil {
+		return v2.HashConfigurationView{}
+	}
+
+	return v2.HashConfigurationView{
+		IncludeQueryParams: this.IncludeQueryParams,
+		ExcludeQueryParams: this.ExcludeQueryParams,
+		IncludeHeaders:     this.IncludeHeaders,
+		ExcludeBody:        this.ExcludeBody,
+		ExcludeBodyPaths:   this.ExcludeBodyPaths,
+	}
+}
+
+// Hash returns the key for a request with the configuration applied
+func (this *HashConfiguration) Hash(r RequestDetails, withHost bool) string {
+	if this == nil {
+		if withHost {
+			return r.Hash()
+		}
+		return r.HashWithoutHost()
+	}
+
+	r = this.Apply(r)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(r.concatenate(withHost))
+	buffer.WriteString(this.concatenateHeaders(r.Headers))
+
+	h := md5.New()
+	io.WriteString(h, buffer.String())
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Apply returns the request with the query parameters and body that are not
+// hashed removed
+func (this *HashConfiguration) Apply(r RequestDetails) RequestDetails {
+	if this == nil {
+		return r
+	}
+
+	r.Query = this.filterQuery(r.Query)
+
+	if this.ExcludeBody {
+		r.Body = ""
+	} else if len(this.ExcludeBodyPaths) > 0 && r.GetContentType() == ContentTypeJSON {
+		r.Body = this.filterBody(r.Body)
+	}
+
+	return r
+}
+
+// filterQuery drops query parameters from the raw query
+func (this *HashConfiguration) filterQuery(rawQuery string) string {
+	if len(this.IncludeQueryParams) == 0 && len(this.ExcludeQueryParams) == 0 {
+		return rawQuery
+	}
+
+	var kept []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		key := queryParamName(param)
+		if len(this.IncludeQueryParams) > 0 && !containsString(this.IncludeQueryParams, key) {
+			continue
+		}
+		if containsString(this.ExcludeQueryParams, key) {
+			continue
+		}
+		kept = append(kept, param)
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func (this *HashConfiguration) filterBody(body string) string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return body
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return body
+	}
+
+	for _, path := range this.ExcludeBodyPaths {
+		segments, err := parseBodyPath(path)
+		if err != nil {
+			continue
+		}
+		data = removeBodyPath(data, segments)
+	}
+
+	filtered, err := json.Marshal(data)
+	if err != nil {
+		return body
+	}
+	return string(filtered)
+}
+
+func (this *HashConfiguration) concatenateHeaders(headers map[string][]string) string {
+	if len(this.IncludeHeaders) == 0 {
+		return ""
+	}
+
+	names := make([]string, len(this.IncludeHeaders))
+	for i, name := range this.IncludeHeaders {
+		names[i] = strings.ToLower(name)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	for _, name := range names {
+		for headerName, values := range headers {
+			if strings.ToLower(headerName) == name {
+				buffer.WriteString(name + ":" + strings.Join(values, ","))
+			}
+		}
+	}
+	return buffer.String()
+}
+
+// bodyPathSegment is either an object key or an array index, an index of -1
+// stands for every element in the array
+type bodyPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseBodyPath parses the dot notation subset of JSONPath, e.g. $.items[*].id
+func parseBodyPath(path string) ([]bodyPathSegment, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("%s is not a valid body path", path)
+	}
+
+	var segments []bodyPathSegment
+	for _, part := range strings.Split(trimmed, ".") {
+		key := part
+		var indexes []string
+
+		if i := strings.Index(part, "["); i != -1 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("%s is not a valid body path", path)
+			}
+			key = part[:i]
+			indexes = strings.Split(part[i+1:len(part)-1], "][")
+		}
+
+		if key != "" {
+			segments = append(segments, bodyPathSegment{key: key})
+		} else if len(indexes) == 0 {
+			return nil, fmt.Errorf("%s is not a valid body path", path)
+		}
+
+		for _, index := range indexes {
+			if index == "*" {
+				segments = append(segments, bodyPathSegment{index: -1, isIndex: true})
+				continue
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("%s is not a valid body path", path)
+			}
+			segments = append(segments, bodyPathSegment{index: i, isIndex: true})
+		}
+	}
+	return segments, nil
+}
+
+func removeBodyPath(data interface{}, segments []bodyPathSegment) interface{} {
+	if len(segments) == 0 {
+		return data
+	}
+
+	segment, last := segments[0], len(segments) == 1
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		if segment.isIndex {
+			return data
+		}
+		if last {
+			delete(value, segment.key)
+		} else if child, ok := value[segment.key]; ok {
+			value[segment.key] = removeBodyPath(child, segments[1:])
+		}
+	case []interface{}:
+		if !segment.isIndex {
+			return data
+		}
+		if segment.index == -1 {
+			if last {
+				return []interface{}{}
+			}
+			for i := range value {
+				value[i] = removeBodyPath(value[i], segments[1:])
+			}
+		} else if segment.index < len(value) {
+			if last {
+				return append(value[:segment.index], value[segment.index+1:]...)
+			}
+			value[segment.index] = removeBodyPath(value[segment.index], segments[1:])
+		}
+	}
+	return data
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestHashConfiguration_Hash_NilConfigurationIsTheSameAsRequestDetailsHash(t *testing.T) {
+	RegisterTestingT(t)
+
+	var unit *HashConfiguration
+
+	request := RequestDetails{
+		Destination: "test.com",
+		Path:        "/path",
+		Method:      "GET",
+		Query:       "a=1",
+	}
+
+	Expect(unit.Hash(request, true)).To(Equal(request.Hash()))
+	Expect(unit.Hash(request, false)).To(Equal(request.HashWithoutHost()))
+}
+
+func TestHashConfiguration_Hash_IgnoresExcludedQueryParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{ExcludeQueryParams: []string{"_"}}
+
+	one := RequestDetails{Path: "/path", Query: "a=1&_=1475000000"}
+	two := RequestDetails{Path: "/path", Query: "a=1&_=1476000000"}
+	three := RequestDetails{Path: "/path", Query: "a=2&_=1476000000"}
+
+	Expect(unit.Hash(one, true)).To(Equal(unit.Hash(two, true)))
+	Expect(unit.Hash(one, true)).ToNot(Equal(unit.Hash(three, true)))
+}
+
+func TestHashConfiguration_Hash_OnlyUsesIncludedQueryParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{IncludeQueryParams: []string{"a"}}
+
+	one := RequestDetails{Path: "/path", Query: "a=1&b=1"}
+	two := RequestDetails{Path: "/path", Query: "b=2&a=1"}
+
+	Expect(unit.Hash(one, true)).To(Equal(unit.Hash(two, true)))
+}
+
+func TestHashConfiguration_Hash_UsesIncludedHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{IncludeHeaders: []string{"X-Tenant"}}
+
+	one := RequestDetails{Path: "/path", Headers: map[string][]string{"X-Tenant": []string{"big"}}}
+	two := RequestDetails{Path: "/path", Headers: map[string][]string{"x-tenant": []string{"big"}, "Other": []string{"1"}}}
+	three := RequestDetails{Path: "/path", Headers: map[string][]string{"X-Tenant": []string{"small"}}}
+
+	Expect(unit.Hash(one, true)).To(Equal(unit.Hash(two, true)))
+	Expect(unit.Hash(one, true)).ToNot(Equal(unit.Hash(three, true)))
+}
+
+func TestHashConfiguration_Hash_IgnoresTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{ExcludeBody: true}
+
+	one := RequestDetails{Path: "/path", Body: "one"}
+	two := RequestDetails{Path: "/path", Body: "two"}
+
+	Expect(unit.Hash(one, true)).To(Equal(unit.Hash(two, true)))
+}
+
+func TestHashConfiguration_Hash_IgnoresExcludedBodyPaths(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{ExcludeBodyPaths: []string{"$.meta.timestamp", "$.items[*].requestId"}}
+
+	headers := map[string][]string{"Content-Type": []string{"application/json"}}
+
+	one := RequestDetails{Path: "/path", Headers: headers, Body: `{"id": 1, "meta": {"timestamp": 1}, "items": [{"requestId": "a", "sku": "x"}]}`}
+	two := RequestDetails{Path: "/path", Headers: headers, Body: `{"id": 1, "meta": {"timestamp": 2}, "items": [{"requestId": "b", "sku": "x"}]}`}
+	three := RequestDetails{Path: "/path", Headers: headers, Body: `{"id": 2, "meta": {"timestamp": 2}, "items": [{"requestId": "b", "sku": "x"}]}`}
+
+	Expect(unit.Hash(one, true)).To(Equal(unit.Hash(two, true)))
+	Expect(unit.Hash(one, true)).ToNot(Equal(unit.Hash(three, true)))
+}
+
+func TestHashConfiguration_Hash_KeepsLargeIntegersInBodiesWithExcludedPaths(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &HashConfiguration{ExcludeBodyPaths: []string{"$.meta.timestamp"}}
+
+	headers := map[string][]string{"Content-Type": []string{"application/json"}}
+
+	one := RequestDetails{Path: "/path", Headers: headers, Body: `{"id": 9007199254740993, "meta": {"timestamp": 1}}`}
+	two := RequestDetails{Path: "/path", Headers: headers, Body: `{"id": 9007199254740992, "meta": {"timestamp": 2}}`}
+
+	Expect(unit.Hash(one, true)).ToNot(Equal(unit.Hash(two, true)))
+}
+
+func Test_parseBodyPath(t *testing.T) {
+	RegisterTestingT(t)
+
+	segments, err := parseBodyPath("$.items[2].id")
+	Expect(err).To(BeNil())
+	Expect(segments).To(Equal([]bodyPathSegment{
+		{key: "items"},
+		{index: 2, isIndex: true},
+		{key: "id"},
+	}))
+
+	_, err = parseBodyPath("$")
+	Expect(err).ToNot(BeNil())
+
+	_, err = parseBodyPath("$.items[a]")
+	Expect(err).ToNot(BeNil())
+}
+
+func TestNewHashConfigurationFromView_ReturnsErrorForInvalidBodyPath(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewHashConfigurationFromView(v2.HashConfigurationView{ExcludeBodyPaths: []string{"$.items[1"}})
+
+	Expect(err).ToNot(BeNil())
+}
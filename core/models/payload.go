@@ -19,11 +19,12 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
-	contentTypeJSON = "application/json"
-	contentTypeXML  = "application/xml"
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
 	otherType       = "otherType"
 )
 
@@ -46,6 +47,12 @@ func init() {
 type RequestResponsePair struct {
 	Response ResponseDetails `json:"response"`
 	Request  RequestDetails  `json:"request"`
+	// Scenario - the name of the scenario the pair is part of, if any
+	Scenario string `json:"scenario,omitempty"`
+	// RequiredScenarioState - the state the scenario has to be in for the pair to match
+	RequiredScenarioState string `json:"requiredScenarioState,omitempty"`
+	// NewScenarioState - the state the scenario moves to once the pair has matched
+	NewScenarioState string `json:"newScenarioState,omitempty"`
 }
 
 func (this RequestResponsePair) Id() string {
@@ -68,11 +75,23 @@ func (this *RequestResponsePair) Encode() ([]byte, error) {
 }
 
 func (this *RequestResponsePair) ConvertToV1RequestResponsePairView() *v1.RequestResponsePairView {
-	return &v1.RequestResponsePairView{Response: this.Response.ConvertToV1ResponseDetailsView(), Request: this.Request.ConvertToV1RequestDetailsView()}
+	return &v1.RequestResponsePairView{
+		Response:              this.Response.ConvertToV1ResponseDetailsView(),
+		Request:               this.Request.ConvertToV1RequestDetailsView(),
+		Scenario:              this.Scenario,
+		RequiredScenarioState: this.RequiredScenarioState,
+		NewScenarioState:      this.NewScenarioState,
+	}
 }
 
 func (this *RequestResponsePair) ConvertToRequestResponsePairView() v2.RequestResponsePairView {
-	return v2.RequestResponsePairView{Response: this.Response.ConvertToResponseDetailsView(), Request: this.Request.ConvertToRequestDetailsView()}
+	return v2.RequestResponsePairView{
+		Response:              this.Response.ConvertToResponseDetailsView(),
+		Request:               this.Request.ConvertToRequestDetailsView(),
+		Scenario:              this.Scenario,
+		RequiredScenarioState: this.RequiredScenarioState,
+		NewScenarioState:      this.NewScenarioState,
+	}
 }
 
 // NewPayloadFromBytes decodes supplied bytes into Payload structure
@@ -89,8 +108,11 @@ func NewRequestResponsePairFromBytes(data []byte) (*RequestResponsePair, error)
 
 func NewRequestResponsePairFromRequestResponsePairView(pairView interfaces.RequestResponsePair) RequestResponsePair {
 	return RequestResponsePair{
-		Response: NewResponseDetailsFromResponse(pairView.GetResponse()),
-		Request:  NewRequestDetailsFromRequest(pairView.GetRequest()),
+		Response:              NewResponseDetailsFromResponse(pairView.GetResponse()),
+		Request:               NewRequestDetailsFromRequest(pairView.GetRequest()),
+		Scenario:              pairView.GetScenario(),
+		RequiredScenarioState: pairView.GetRequiredScenarioState(),
+		NewScenarioState:      pairView.GetNewScenarioState(),
 	}
 }
 
@@ -103,6 +125,8 @@ type RequestDetails struct {
 	Query       string              `json:"query"`
 	Body        string              `json:"body"`
 	Headers     map[string][]string `json:"headers"`
+	// HeaderOrder - the name of each header line in the order and casing it was received
+	HeaderOrder []string `json:"headerOrder,omitempty"`
 }
 
 func NewRequestDetailsFromHttpRequest(req *http.Request) (RequestDetails, error) {
@@ -120,11 +144,14 @@ func NewRequestDetailsFromHttpRequest(req *http.Request) (RequestDetails, error)
 		return RequestDetails{}, err
 	}
 
+	// compressed bodies are matched on their content
+	reqBody, _ = DecodeContent(req.Header, reqBody)
+
 	requestDetails := RequestDetails{
 		Path:        req.URL.Path,
 		Method:      req.Method,
 		Destination: req.Host,
-		Scheme:      req.URL.Scheme,
+		Scheme:      requestScheme(req),
 		Query:       req.URL.RawQuery,
 		Body:        string(reqBody),
 		Headers:     req.Header,
@@ -132,6 +159,19 @@ func NewRequestDetailsFromHttpRequest(req *http.Request) (RequestDetails, error)
 	return requestDetails, nil
 }
 
+// requestScheme returns the scheme of a request. Proxied requests carry it in
+// the URL, including MITM'd HTTPS requests, while requests made directly to the
+// webserver only have a relative URL so the connection is checked instead.
+func requestScheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
 func extractRequestBody(req *http.Request) (extract []byte, err error) {
 	save := req.Body
 	savecl := req.ContentLength
@@ -164,19 +204,36 @@ func CopyBody(body io.ReadCloser) (resp1, resp2 io.ReadCloser, err error) {
 }
 
 func NewRequestDetailsFromRequest(data interfaces.Request) RequestDetails {
+	body := PointerToString(data.GetBody())
+	if data.GetEncodedBody() {
+		decoded, _ := base64.StdEncoding.DecodeString(body)
+		body = string(decoded)
+	}
+
 	return RequestDetails{
 		Path:        PointerToString(data.GetPath()),
 		Method:      PointerToString(data.GetMethod()),
 		Destination: PointerToString(data.GetDestination()),
 		Scheme:      PointerToString(data.GetScheme()),
 		Query:       PointerToString(data.GetQuery()),
-		Body:        PointerToString(data.GetBody()),
+		Body:        body,
 		Headers:     data.GetHeaders(),
+		HeaderOrder: data.GetHeaderOrder(),
 	}
 }
 
+// encodeRequestBody base64 encodes a body which is not text, such as a gRPC
+// message, so that it can be exported as JSON
+func encodeRequestBody(body string) (string, bool) {
+	if utf8.ValidString(body) {
+		return body, false
+	}
+	return base64.StdEncoding.EncodeToString([]byte(body)), true
+}
+
 func (this *RequestDetails) ConvertToV1RequestDetailsView() v1.RequestDetailsView {
 	s := "recording"
+	body, encoded := encodeRequestBody(this.Body)
 	return v1.RequestDetailsView{
 		RequestType: &s,
 		Path:        &this.Path,
@@ -184,13 +241,16 @@ func (this *RequestDetails) ConvertToV1RequestDetailsView() v1.RequestDetailsVie
 		Destination: &this.Destination,
 		Scheme:      &this.Scheme,
 		Query:       &this.Query,
-		Body:        &this.Body,
+		Body:        &body,
+		EncodedBody: encoded,
 		Headers:     this.Headers,
+		HeaderOrder: this.HeaderOrder,
 	}
 }
 
 func (this *RequestDetails) ConvertToRequestDetailsView() v2.RequestDetailsView {
 	s := "recording"
+	body, encoded := encodeRequestBody(this.Body)
 	return v2.RequestDetailsView{
 		RequestType: &s,
 		Path:        &this.Path,
@@ -198,8 +258,10 @@ func (this *RequestDetails) ConvertToRequestDetailsView() v2.RequestDetailsView
 		Destination: &this.Destination,
 		Scheme:      &this.Scheme,
 		Query:       &this.Query,
-		Body:        &this.Body,
+		Body:        &body,
+		EncodedBody: encoded,
 		Headers:     this.Headers,
+		HeaderOrder: this.HeaderOrder,
 	}
 }
 
@@ -212,24 +274,56 @@ func (r *RequestDetails) concatenate(withHost bool) string {
 
 	buffer.WriteString(r.Path)
 	buffer.WriteString(r.Method)
-	buffer.WriteString(r.Query)
-	if len(r.Body) > 0 {
-		ct := r.getContentType()
-
-		if ct == contentTypeJSON || ct == contentTypeXML {
-			buffer.WriteString(r.minifyBody(ct))
-		} else {
-			log.WithFields(log.Fields{
-				"content-type": r.Headers["Content-Type"],
-			}).Debug("unknown content type")
-
-			buffer.WriteString(r.Body)
-		}
-	}
+	buffer.WriteString(SortQuery(r.Query))
+	buffer.WriteString(r.CanonicalBody())
 
 	return buffer.String()
 }
 
+// CanonicalBody returns the body the way it is hashed. JSON and XML bodies are
+// canonicalised, anything else is left as it is.
+func (r *RequestDetails) CanonicalBody() string {
+	if len(r.Body) == 0 {
+		return r.Body
+	}
+
+	ct := r.GetContentType()
+	if ct == ContentTypeJSON || ct == ContentTypeXML {
+		return r.canonicaliseBody(ct)
+	}
+
+	log.WithFields(log.Fields{
+		"content-type": r.Headers["Content-Type"],
+	}).Debug("unknown content type")
+
+	return r.Body
+}
+
+// canonicaliseBody returns the body in a form where structurally equal JSON or XML documents
+// are identical. Bodies which can't be parsed fall back to being minified.
+func (r *RequestDetails) canonicaliseBody(mediaType string) string {
+	var canonical string
+	var err error
+
+	if mediaType == ContentTypeJSON {
+		canonical, err = canonicaliseJSON(r.Body)
+	} else {
+		canonical, err = canonicaliseXML(r.Body)
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err.Error(),
+			"destination": r.Destination,
+			"path":        r.Path,
+			"method":      r.Method,
+		}).Debugf("failed to canonicalise request body, media type given: %s. Falling back to minifying", mediaType)
+		return r.minifyBody(mediaType)
+	}
+
+	return canonical
+}
+
 func (r *RequestDetails) minifyBody(mediaType string) (minified string) {
 	var err error
 	minified, err = minifiers.String(mediaType, r.Body)
@@ -257,13 +351,15 @@ func (r *RequestDetails) HashWithoutHost() string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func (r *RequestDetails) getContentType() string {
+// GetContentType returns ContentTypeJSON or ContentTypeXML when the Content-Type header
+// says the body is JSON or XML
+func (r *RequestDetails) GetContentType() string {
 	for _, v := range r.Headers["Content-Type"] {
 		if rxJSON.MatchString(v) {
-			return contentTypeJSON
+			return ContentTypeJSON
 		}
 		if rxXML.MatchString(v) {
-			return contentTypeXML
+			return ContentTypeXML
 		}
 	}
 	return otherType
@@ -276,6 +372,23 @@ type ResponseDetails struct {
 	Status  int                 `json:"status"`
 	Body    string              `json:"body"`
 	Headers map[string][]string `json:"headers"`
+	// HeaderOrder - the name of each header line in the order and casing it was received
+	HeaderOrder []string `json:"headerOrder,omitempty"`
+	// ContentEncoding - the coding the body was decoded from on capture, it is
+	// encoded again for clients which accept it
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// Trailers - sent after the body, such as the grpc-status of a gRPC response
+	Trailers map[string][]string `json:"trailers,omitempty"`
+	// Templated - when true, the body and headers are rendered with the request before being returned
+	Templated bool `json:"templated,omitempty"`
+	// Fault - when set, the response is replaced or cut short to simulate a failure
+	Fault *Fault `json:"fault,omitempty"`
+	// Delay - when set, the response is delayed on top of any global delay for the request
+	Delay *ResponseDelay `json:"delay,omitempty"`
+	// Events - the timing of a streamed response, the body holds all of their data
+	Events []ResponseEvent `json:"events,omitempty"`
+	// WebSocketMessages - the messages exchanged after a WebSocket handshake
+	WebSocketMessages []WebSocketMessage `json:"webSocketMessages,omitempty"`
 }
 
 func NewResponseDetailsFromResponse(data interfaces.Response) ResponseDetails {
@@ -286,7 +399,19 @@ func NewResponseDetailsFromResponse(data interfaces.Response) ResponseDetails {
 		body = string(decoded)
 	}
 
-	return ResponseDetails{Status: data.GetStatus(), Body: body, Headers: data.GetHeaders()}
+	return ResponseDetails{
+		Status:            data.GetStatus(),
+		Body:              body,
+		Headers:           data.GetHeaders(),
+		HeaderOrder:       data.GetHeaderOrder(),
+		ContentEncoding:   data.GetContentEncoding(),
+		Trailers:          data.GetTrailers(),
+		Templated:         data.GetTemplated(),
+		Fault:             NewFaultFromView(data.GetFault()),
+		Delay:             NewDelayFromView(data.GetDelay()),
+		Events:            NewResponseEventsFromView(data.GetEvents(), data.GetEncodedBody()),
+		WebSocketMessages: NewWebSocketMessagesFromView(data.GetWebSocketMessages()),
+	}
 }
 
 // This function will create a JSON appriopriate version of ResponseDetails for the v1 API
@@ -316,7 +441,20 @@ func (r *ResponseDetails) ConvertToV1ResponseDetailsView() v1.ResponseDetailsVie
 		body = base64.StdEncoding.EncodeToString([]byte(r.Body))
 	}
 
-	return v1.ResponseDetailsView{Status: r.Status, Body: body, Headers: r.Headers, EncodedBody: needsEncoding}
+	return v1.ResponseDetailsView{
+		Status:            r.Status,
+		Body:              body,
+		Headers:           r.Headers,
+		HeaderOrder:       r.HeaderOrder,
+		ContentEncoding:   r.ContentEncoding,
+		Trailers:          r.Trailers,
+		EncodedBody:       needsEncoding,
+		Templated:         r.Templated,
+		Fault:             r.Fault.ConvertToFaultView(),
+		Delay:             r.Delay.ConvertToDelayView(),
+		Events:            convertToResponseEventViews(r.Events, needsEncoding),
+		WebSocketMessages: convertToWebSocketMessageViews(r.WebSocketMessages),
+	}
 }
 
 // This function will create a JSON appriopriate version of ResponseDetails for the v2 API
@@ -346,5 +484,18 @@ func (r *ResponseDetails) ConvertToResponseDetailsView() v2.ResponseDetailsView
 		body = base64.StdEncoding.EncodeToString([]byte(r.Body))
 	}
 
-	return v2.ResponseDetailsView{Status: r.Status, Body: body, Headers: r.Headers, EncodedBody: needsEncoding}
+	return v2.ResponseDetailsView{
+		Status:            r.Status,
+		Body:              body,
+		Headers:           r.Headers,
+		HeaderOrder:       r.HeaderOrder,
+		ContentEncoding:   r.ContentEncoding,
+		Trailers:          r.Trailers,
+		EncodedBody:       needsEncoding,
+		Templated:         r.Templated,
+		Fault:             r.Fault.ConvertToFaultView(),
+		Delay:             r.Delay.ConvertToDelayView(),
+		Events:            convertToResponseEventViews(r.Events, needsEncoding),
+		WebSocketMessages: convertToWebSocketMessageViews(r.WebSocketMessages),
+	}
 }
@@ -3,11 +3,13 @@ package models
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/tls"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	. "github.com/SpectoLabs/hoverfly/core/util"
 	. "github.com/onsi/gomega"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"testing"
 )
@@ -396,6 +398,46 @@ func TestRequestDetails_ConvertToRequestDetailsView(t *testing.T) {
 	Expect(requestDetailsView.Headers).To(Equal(requestDetails.Headers))
 }
 
+func TestRequestDetails_ConvertToRequestDetailsView_EncodesABinaryBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	requestDetails := RequestDetails{Method: "POST", Path: "/helloworld.Greeter/SayHello", Body: "\x00\x00\x00\x00\x01\xff"}
+
+	requestDetailsView := requestDetails.ConvertToRequestDetailsView()
+	Expect(requestDetailsView.EncodedBody).To(BeTrue())
+	Expect(*requestDetailsView.Body).To(Equal("AAAAAAH/"))
+
+	Expect(NewRequestDetailsFromRequest(requestDetailsView).Body).To(Equal(requestDetails.Body))
+}
+
+func TestResponseDetails_ConvertToResponseDetailsView_KeepsTrailers(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status:   200,
+		Headers:  map[string][]string{"Content-Type": []string{"application/grpc"}},
+		Trailers: map[string][]string{"Grpc-Status": []string{"0"}},
+	}
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.Trailers).To(Equal(response.Trailers))
+	Expect(NewResponseDetailsFromResponse(view).Trailers).To(Equal(response.Trailers))
+}
+
+func TestResponseDetails_ConvertToResponseDetailsView_KeepsHeaderOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status:      200,
+		Headers:     map[string][]string{"Set-Cookie": []string{"b=2", "a=1"}, "X-Legacy": []string{"a"}},
+		HeaderOrder: []string{"Set-Cookie", "x-LEGACY", "Set-Cookie"},
+	}
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.HeaderOrder).To(Equal(response.HeaderOrder))
+	Expect(NewResponseDetailsFromResponse(view).HeaderOrder).To(Equal(response.HeaderOrder))
+}
+
 // Helper function for gzipping strings
 func GzipString(s string) string {
 	var b bytes.Buffer
@@ -493,3 +535,29 @@ func TestRequestDetailsView_ConvertToRequestDetails(t *testing.T) {
 	Expect(requestDetails.Query).To(Equal(*requestDetailsView.Query))
 	Expect(requestDetails.Headers).To(Equal(requestDetailsView.Headers))
 }
+
+func TestNewRequestDetailsFromHttpRequest_UsesTheSchemeOfProxiedRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "https://test.com/path", nil)
+
+	requestDetails, err := NewRequestDetailsFromHttpRequest(req)
+	Expect(err).To(BeNil())
+	Expect(requestDetails.Scheme).To(Equal("https"))
+}
+
+func TestNewRequestDetailsFromHttpRequest_FillsInTheSchemeOfWebserverRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "/path", nil)
+
+	requestDetails, err := NewRequestDetailsFromHttpRequest(req)
+	Expect(err).To(BeNil())
+	Expect(requestDetails.Scheme).To(Equal("http"))
+
+	req.TLS = &tls.ConnectionState{}
+
+	requestDetails, err = NewRequestDetailsFromHttpRequest(req)
+	Expect(err).To(BeNil())
+	Expect(requestDetails.Scheme).To(Equal("https"))
+}
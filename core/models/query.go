@@ -0,0 +1,58 @@
+package models
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SortQuery orders the parameters of a raw query string by name, so that
+// ?a=1&b=2 and ?b=2&a=1 give the same string. Values of a repeated parameter
+// keep the order they were sent in and nothing is re-encoded.
+func SortQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	sort.SliceStable(params, func(i, j int) bool {
+		return queryParamName(params[i]) < queryParamName(params[j])
+	})
+
+	return strings.Join(params, "&")
+}
+
+// ParseQuery splits a raw query string into its parameters and their values.
+// Malformed parameters are skipped rather than failing the whole query.
+func ParseQuery(rawQuery string) map[string][]string {
+	params := map[string][]string{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		parts := strings.SplitN(param, "=", 2)
+		name, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			continue
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			if value, err = url.QueryUnescape(parts[1]); err != nil {
+				continue
+			}
+		}
+
+		params[name] = append(params[name], value)
+	}
+	return params
+}
+
+func queryParamName(param string) string {
+	name := strings.SplitN(param, "=", 2)[0]
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSortQuery_OrdersParametersByName(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(SortQuery("b=2&a=1")).To(Equal("a=1&b=2"))
+	Expect(SortQuery("")).To(Equal(""))
+}
+
+func TestSortQuery_KeepsTheOrderOfRepeatedParameters(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(SortQuery("id=2&a=1&id=1")).To(Equal("a=1&id=2&id=1"))
+}
+
+func TestParseQuery_ReturnsEveryValueOfEachParameter(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ParseQuery("id=2&flag&name=a%20b&id=1")).To(Equal(map[string][]string{
+		"id":   []string{"2", "1"},
+		"flag": []string{""},
+		"name": []string{"a b"},
+	}))
+}
+
+func TestParseQuery_SkipsMalformedParameters(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ParseQuery("a=%zz&b=1")).To(Equal(map[string][]string{
+		"b": []string{"1"},
+	}))
+}
+
+func TestRequestDetails_Hash_IgnoresTheOrderOfQueryParameters(t *testing.T) {
+	RegisterTestingT(t)
+
+	one := RequestDetails{Destination: "test.com", Path: "/", Method: "GET", Query: "a=1&b=2"}
+	two := RequestDetails{Destination: "test.com", Path: "/", Method: "GET", Query: "b=2&a=1"}
+	three := RequestDetails{Destination: "test.com", Path: "/", Method: "GET", Query: "a=2&b=1"}
+
+	Expect(one.Hash()).To(Equal(two.Hash()))
+	Expect(one.Hash()).ToNot(Equal(three.Hash()))
+}
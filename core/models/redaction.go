@@ -0,0 +1,266 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// DefaultRedactionMask replaces masked values when no mask is configured
+const DefaultRedactionMask = "REDACTED"
+
+// Redaction removes sensitive data from request response pairs before they are
+// stored and again when they are exported. Requests are hashed as they are once
+// redacted, so the values it removes play no part in matching. A nil Redaction
+// leaves pairs as they are.
+type Redaction struct {
+	// Mask - replaces the values of masked headers and body paths
+	Mask string
+	// DropHeaders - headers which are removed
+	DropHeaders []string
+	// MaskHeaders - headers whose values are masked
+	MaskHeaders []string
+	// BodyReplacements - regular expressions replaced in bodies, events and text messages
+	BodyReplacements []BodyReplacement
+	// MaskBodyPaths - JSON paths, such as $.user.email, whose values are masked in JSON bodies
+	MaskBodyPaths []string
+}
+
+type BodyReplacement struct {
+	Pattern     string
+	Replacement string
+
+	regex *regexp.Regexp
+}
+
+func NewRedactionFromView(view v2.RedactionView) (*Redaction, error) {
+	var replacements []BodyReplacement
+	for _, replacementView := range view.BodyReplacements {
+		regex, err := regexp.Compile(replacementView.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid regular expression", replacementView.Pattern)
+		}
+		replacements = append(replacements, BodyReplacement{
+			Pattern:     replacementView.Pattern,
+			Replacement: replacementView.Replacement,
+			regex:       regex,
+		})
+	}
+
+	for _, path := range view.MaskBodyPaths {
+		if _, err := parseBodyPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	mask := view.Mask
+	if mask == "" {
+		mask = DefaultRedactionMask
+	}
+
+	return &Redaction{
+		Mask:             mask,
+		DropHeaders:      view.DropHeaders,
+		MaskHeaders:      view.MaskHeaders,
+		BodyReplacements: replacements,
+		MaskBodyPaths:    view.MaskBodyPaths,
+	}, nil
+}
+
+func (this *Redaction) ConvertToRedactionView() v2.RedactionView {
+	if this == nil {
+		return v2.RedactionView{}
+	}
+
+	var replacements []v2.BodyReplacementView
+	for _, replacement := range this.BodyReplacements {
+		replacements = append(replacements, v2.BodyReplacementView{
+			Pattern:     replacement.Pattern,
+			Replacement: replacement.Replacement,
+		})
+	}
+
+	return v2.RedactionView{
+		Mask:             this.Mask,
+		DropHeaders:      this.DropHeaders,
+		MaskHeaders:      this.MaskHeaders,
+		BodyReplacements: replacements,
+		MaskBodyPaths:    this.MaskBodyPaths,
+	}
+}
+
+// Apply returns the pair with the redaction applied to its request and response,
+// the headers of the pair it is given are left untouched
+func (this *Redaction) Apply(pair RequestResponsePair) RequestResponsePair {
+	if this == nil {
+		return pair
+	}
+
+	pair.Request = this.RedactRequest(pair.Request)
+
+	body := this.redactBody(pair.Response.Body)
+	pair.Response.Headers = this.redactHeaders(pair.Response.Headers)
+	pair.Response.Trailers = this.redactHeaders(pair.Response.Trailers)
+	if body != pair.Response.Body {
+		setContentLength(pair.Response.Headers, body)
+		pair.Response.Body = body
+	}
+
+	if len(pair.Response.Events) > 0 {
+		events := make([]ResponseEvent, len(pair.Response.Events))
+		for i, event := range pair.Response.Events {
+			event.Data = this.redactBody(event.Data)
+			events[i] = event
+		}
+		pair.Response.Events = events
+	}
+
+	if len(pair.Response.WebSocketMessages) > 0 {
+		messages := make([]WebSocketMessage, len(pair.Response.WebSocketMessages))
+		for i, message := range pair.Response.WebSocketMessages {
+			if message.Type == WebSocketText {
+				message.Data = this.redactBody(message.Data)
+			}
+			messages[i] = message
+		}
+		pair.Response.WebSocketMessages = messages
+	}
+
+	return pair
+}
+
+// RedactRequest returns the request with the redaction applied, the headers of
+// the request it is given are left untouched
+func (this *Redaction) RedactRequest(request RequestDetails) RequestDetails {
+	if this == nil {
+		return request
+	}
+
+	body := this.redactBody(request.Body)
+	request.Headers = this.redactHeaders(request.Headers)
+	if body != request.Body {
+		setContentLength(request.Headers, body)
+		request.Body = body
+	}
+	return request
+}
+
+// setContentLength updates a Content-Length header to the length of the body
+func setContentLength(headers map[string][]string, body string) {
+	for name := range headers {
+		if http.CanonicalHeaderKey(name) == "Content-Length" {
+			headers[name] = []string{strconv.Itoa(len(body))}
+		}
+	}
+}
+
+func (this *Redaction) redactHeaders(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+
+	redacted := map[string][]string{}
+	for name, values := range headers {
+		if containsHeader(this.DropHeaders, name) {
+			continue
+		}
+
+		if containsHeader(this.MaskHeaders, name) {
+			masked := make([]string, len(values))
+			for i := range values {
+				masked[i] = this.Mask
+			}
+			values = masked
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+func (this *Redaction) redactBody(body string) string {
+	if body == "" {
+		return body
+	}
+
+	for _, replacement := range this.BodyReplacements {
+		body = replacement.regex.ReplaceAllString(body, replacement.Replacement)
+	}
+
+	if len(this.MaskBodyPaths) == 0 {
+		return body
+	}
+
+	// numbers are kept as they were written
+	decoder := json.NewDecoder(bytes.NewReader([]byte(body)))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return body
+	}
+
+	// bodies are only marshalled again when a path was masked, as that reorders
+	// the keys of objects and escapes HTML characters
+	masked := false
+	for _, path := range this.MaskBodyPaths {
+		segments, err := parseBodyPath(path)
+		if err != nil {
+			continue
+		}
+		var found bool
+		data, found = maskBodyPath(data, segments, this.Mask)
+		masked = masked || found
+	}
+	if !masked {
+		return body
+	}
+
+	marshalled, err := json.Marshal(data)
+	if err != nil {
+		return body
+	}
+	return string(marshalled)
+}
+
+// maskBodyPath masks the values at the path, returning whether there were any
+func maskBodyPath(data interface{}, segments []bodyPathSegment, mask string) (interface{}, bool) {
+	if len(segments) == 0 {
+		return mask, true
+	}
+
+	segment := segments[0]
+	masked := false
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		if child, ok := value[segment.key]; ok && !segment.isIndex {
+			value[segment.key], masked = maskBodyPath(child, segments[1:], mask)
+		}
+	case []interface{}:
+		if !segment.isIndex {
+			return data, false
+		}
+		for i := range value {
+			if segment.index == -1 || segment.index == i {
+				var found bool
+				value[i], found = maskBodyPath(value[i], segments[1:], mask)
+				masked = masked || found
+			}
+		}
+	}
+	return data, masked
+}
+
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if http.CanonicalHeaderKey(n) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNewRedactionFromView_RejectsInvalidPatternsAndPaths(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewRedactionFromView(v2.RedactionView{BodyReplacements: []v2.BodyReplacementView{{Pattern: "(token"}}})
+	Expect(err).ToNot(BeNil())
+
+	_, err = NewRedactionFromView(v2.RedactionView{MaskBodyPaths: []string{"$"}})
+	Expect(err).ToNot(BeNil())
+
+	redaction, err := NewRedactionFromView(v2.RedactionView{})
+	Expect(err).To(BeNil())
+	Expect(redaction.Mask).To(Equal(DefaultRedactionMask))
+}
+
+func TestRedaction_ApplyDropsAndMasksHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	redaction, err := NewRedactionFromView(v2.RedactionView{
+		DropHeaders: []string{"cookie"},
+		MaskHeaders: []string{"Authorization", "Set-Cookie"},
+	})
+	Expect(err).To(BeNil())
+
+	headers := map[string][]string{
+		"Authorization": []string{"Bearer secret"},
+		"Cookie":        []string{"session=1"},
+		"Accept":        []string{"*/*"},
+	}
+	pair := RequestResponsePair{
+		Request: RequestDetails{Headers: headers},
+		Response: ResponseDetails{Headers: map[string][]string{
+			"Set-Cookie": []string{"a=1", "b=2"},
+		}},
+	}
+
+	redacted := redaction.Apply(pair)
+	Expect(redacted.Request.Headers).To(Equal(map[string][]string{
+		"Authorization": []string{"REDACTED"},
+		"Accept":        []string{"*/*"},
+	}))
+	Expect(redacted.Response.Headers["Set-Cookie"]).To(Equal([]string{"REDACTED", "REDACTED"}))
+
+	// the original is left as it was
+	Expect(headers["Authorization"]).To(Equal([]string{"Bearer secret"}))
+}
+
+func TestRedaction_ApplyReplacesPatternsAndMasksJSONPaths(t *testing.T) {
+	RegisterTestingT(t)
+
+	redaction, err := NewRedactionFromView(v2.RedactionView{
+		Mask: "***",
+		BodyReplacements: []v2.BodyReplacementView{
+			{Pattern: `\d{4}-\d{4}-\d{4}-\d{4}`, Replacement: "XXXX-XXXX-XXXX-XXXX"},
+		},
+		MaskBodyPaths: []string{"$.user.email", "$.cards[*].cvv"},
+	})
+	Expect(err).To(BeNil())
+
+	pair := RequestResponsePair{
+		Request: RequestDetails{Body: "card=1234-5678-9012-3456"},
+		Response: ResponseDetails{
+			Body:   `{"id": 12345678901234567890, "user": {"email": "a@b.com"}, "cards": [{"cvv": 123}, {"cvv": 456}]}`,
+			Events: []ResponseEvent{{Data: "card 1234-5678-9012-3456"}},
+		},
+	}
+
+	redacted := redaction.Apply(pair)
+	Expect(redacted.Request.Body).To(Equal("card=XXXX-XXXX-XXXX-XXXX"))
+	Expect(redacted.Response.Body).To(MatchJSON(`{"id": 12345678901234567890, "user": {"email": "***"}, "cards": [{"cvv": "***"}, {"cvv": "***"}]}`))
+	Expect(redacted.Response.Events[0].Data).To(Equal("card XXXX-XXXX-XXXX-XXXX"))
+	Expect(pair.Response.Events[0].Data).To(Equal("card 1234-5678-9012-3456"))
+}
+
+func TestRedaction_NilLeavesPairsAsTheyAre(t *testing.T) {
+	RegisterTestingT(t)
+
+	var redaction *Redaction
+	pair := RequestResponsePair{Request: RequestDetails{Body: "secret"}}
+
+	Expect(redaction.Apply(pair)).To(Equal(pair))
+	Expect(redaction.ConvertToRedactionView()).To(Equal(v2.RedactionView{}))
+}
+
+func TestRedaction_ApplyUpdatesTheContentLengthOfRedactedBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	redaction, err := NewRedactionFromView(v2.RedactionView{MaskBodyPaths: []string{"$.token"}})
+	Expect(err).To(BeNil())
+
+	pair := RequestResponsePair{
+		Request: RequestDetails{
+			Body:    `{"token": "abc"}`,
+			Headers: map[string][]string{"Content-Length": []string{"16"}},
+		},
+		Response: ResponseDetails{
+			Body:    `{"token": "a-much-longer-token"}`,
+			Headers: map[string][]string{"content-length": []string{"32"}},
+		},
+	}
+
+	redacted := redaction.Apply(pair)
+	Expect(redacted.Request.Headers["Content-Length"]).To(Equal([]string{fmt.Sprint(len(redacted.Request.Body))}))
+	Expect(redacted.Response.Headers["content-length"]).To(Equal([]string{fmt.Sprint(len(redacted.Response.Body))}))
+	Expect(pair.Response.Headers["content-length"]).To(Equal([]string{"32"}))
+}
+
+func TestRedaction_ApplyLeavesJSONBodiesWithoutAMaskedPathAsTheyWere(t *testing.T) {
+	RegisterTestingT(t)
+
+	redaction, err := NewRedactionFromView(v2.RedactionView{MaskBodyPaths: []string{"$.token", "$.items[*].secret"}})
+	Expect(err).To(BeNil())
+
+	body := `{"z": 1, "a": "<b>&</b>", "items": [{"id": 1}]}`
+	pair := RequestResponsePair{
+		Response: ResponseDetails{
+			Body:    body,
+			Headers: map[string][]string{"Content-Length": []string{fmt.Sprint(len(body))}},
+		},
+	}
+
+	redacted := redaction.Apply(pair)
+	Expect(redacted.Response.Body).To(Equal(body))
+	Expect(redacted.Response.Headers["Content-Length"]).To(Equal([]string{fmt.Sprint(len(body))}))
+}
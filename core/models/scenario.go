@@ -0,0 +1,26 @@
+package models
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+)
+
+// Key returns the key the pair is stored under. Pairs which are part of a scenario
+// are stored under a key for the scenario and the state they require, so the same
+// request can have a different response in each state.
+func (this RequestResponsePair) Key(hashConfiguration *HashConfiguration, withHost bool) string {
+	return ScenarioKey(hashConfiguration.Hash(this.Request, withHost), this.Scenario, this.RequiredScenarioState)
+}
+
+// ScenarioKey returns the key for a request in a scenario state. Requests which
+// are not in a scenario keep their key.
+func ScenarioKey(key, scenario, state string) string {
+	if scenario == "" {
+		return key
+	}
+
+	h := md5.New()
+	io.WriteString(h, key+"\x00"+scenario+"\x00"+state)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// ResponseThrottle slows down the responses to matching requests. TimeToFirstByte
+// and ChunkDelay are in milliseconds; any field left at zero is not applied.
+type ResponseThrottle struct {
+	UrlPattern      string
+	HttpMethod      string
+	BytesPerSecond  int
+	TimeToFirstByte int
+	ChunkSize       int
+	ChunkDelay      int
+}
+
+type ResponseThrottleList []ResponseThrottle
+
+func NewResponseThrottleListFromView(views []v2.ResponseThrottleView) (ResponseThrottleList, error) {
+	throttles := ResponseThrottleList{}
+
+	for _, view := range views {
+		if _, err := regexp.Compile(view.UrlPattern); err != nil {
+			return nil, fmt.Errorf("Response throttle has an invalid pattern: %s", view.UrlPattern)
+		}
+
+		if view.BytesPerSecond < 0 || view.TimeToFirstByte < 0 || view.ChunkSize < 0 || view.ChunkDelay < 0 {
+			return nil, fmt.Errorf("Response throttle for %s cannot have negative values", view.UrlPattern)
+		}
+
+		if view.ChunkDelay > 0 && view.ChunkSize == 0 {
+			return nil, fmt.Errorf("Response throttle for %s has a chunk delay without a chunk size", view.UrlPattern)
+		}
+
+		throttles = append(throttles, ResponseThrottle{
+			UrlPattern:      view.UrlPattern,
+			HttpMethod:      view.HttpMethod,
+			BytesPerSecond:  view.BytesPerSecond,
+			TimeToFirstByte: view.TimeToFirstByte,
+			ChunkSize:       view.ChunkSize,
+			ChunkDelay:      view.ChunkDelay,
+		})
+	}
+
+	return throttles, nil
+}
+
+// GetThrottle returns the first throttle matching the request
+func (this ResponseThrottleList) GetThrottle(request RequestDetails) *ResponseThrottle {
+	for _, val := range this {
+		match := regexp.MustCompile(val.UrlPattern).MatchString(request.Destination + request.Path)
+		if match && (val.HttpMethod == "" || strings.EqualFold(val.HttpMethod, request.Method)) {
+			log.Info("Found response throttle setting for this request host: ", val)
+			throttle := val
+			return &throttle
+		}
+	}
+	return nil
+}
+
+func (this ResponseThrottleList) ConvertToResponseThrottleViews() []v2.ResponseThrottleView {
+	views := []v2.ResponseThrottleView{}
+
+	for _, throttle := range this {
+		views = append(views, v2.ResponseThrottleView{
+			UrlPattern:      throttle.UrlPattern,
+			HttpMethod:      throttle.HttpMethod,
+			BytesPerSecond:  throttle.BytesPerSecond,
+			TimeToFirstByte: throttle.TimeToFirstByte,
+			ChunkSize:       throttle.ChunkSize,
+			ChunkDelay:      throttle.ChunkDelay,
+		})
+	}
+
+	return views
+}
+
+// ThrottledBody replays a response body at the pace of its throttle. It waits
+// for the time to first byte, then hands out at most a chunk per read, waiting
+// the chunk delay between chunks and keeping under the bandwidth limit.
+type ThrottledBody struct {
+	throttle ResponseThrottle
+	body     []byte
+	read     int
+	started  bool
+	sleep    func(time.Duration)
+}
+
+func NewThrottledBody(throttle ResponseThrottle, body []byte) *ThrottledBody {
+	return &ThrottledBody{
+		throttle: throttle,
+		body:     body,
+		sleep:    time.Sleep,
+	}
+}
+
+func (this *ThrottledBody) Read(p []byte) (int, error) {
+	if !this.started {
+		this.started = true
+		this.sleep(time.Duration(this.throttle.TimeToFirstByte) * time.Millisecond)
+	} else if this.read < len(this.body) {
+		this.sleep(time.Duration(this.throttle.ChunkDelay) * time.Millisecond)
+	}
+
+	if this.read >= len(this.body) {
+		return 0, io.EOF
+	}
+
+	size := len(p)
+	if this.throttle.ChunkSize > 0 && size > this.throttle.ChunkSize {
+		size = this.throttle.ChunkSize
+	}
+	// keep each read to a tenth of a second of bandwidth so the pace is even
+	if this.throttle.BytesPerSecond > 0 {
+		limit := this.throttle.BytesPerSecond / 10
+		if limit == 0 {
+			limit = 1
+		}
+		if size > limit {
+			size = limit
+		}
+	}
+
+	n := copy(p[:size], this.body[this.read:])
+	this.read += n
+
+	if this.throttle.BytesPerSecond > 0 {
+		this.sleep(time.Duration(n) * time.Second / time.Duration(this.throttle.BytesPerSecond))
+	}
+
+	return n, nil
+}
+
+func (this *ThrottledBody) Close() error { return nil }
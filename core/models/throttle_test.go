@@ -0,0 +1,101 @@
+package models
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func readThrottled(throttle ResponseThrottle, body string) ([]string, []time.Duration) {
+	var sleeps []time.Duration
+	throttled := NewThrottledBody(throttle, []byte(body))
+	throttled.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	var chunks []string
+	buffer := make([]byte, 1024)
+	for {
+		n, err := throttled.Read(buffer)
+		if n > 0 {
+			chunks = append(chunks, string(buffer[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	return chunks, sleeps
+}
+
+func TestThrottledBody_WaitsForTheTimeToFirstByte(t *testing.T) {
+	RegisterTestingT(t)
+
+	chunks, sleeps := readThrottled(ResponseThrottle{TimeToFirstByte: 300}, "hello world")
+
+	Expect(chunks).To(Equal([]string{"hello world"}))
+	Expect(sleeps[0]).To(Equal(300 * time.Millisecond))
+}
+
+func TestThrottledBody_ReplaysChunksWithDelaysBetweenThem(t *testing.T) {
+	RegisterTestingT(t)
+
+	chunks, sleeps := readThrottled(ResponseThrottle{ChunkSize: 4, ChunkDelay: 50}, "hello world")
+
+	Expect(chunks).To(Equal([]string{"hell", "o wo", "rld"}))
+	Expect(sleeps).To(Equal([]time.Duration{0, 50 * time.Millisecond, 50 * time.Millisecond}))
+}
+
+func TestThrottledBody_KeepsUnderTheBandwidthLimit(t *testing.T) {
+	RegisterTestingT(t)
+
+	chunks, sleeps := readThrottled(ResponseThrottle{BytesPerSecond: 20}, "hello world")
+
+	Expect(chunks).To(Equal([]string{"he", "ll", "o ", "wo", "rl", "d"}))
+
+	var total time.Duration
+	for _, sleep := range sleeps {
+		total += sleep
+	}
+	Expect(total).To(Equal(550 * time.Millisecond))
+}
+
+func TestResponseThrottleList_GetThrottle(t *testing.T) {
+	RegisterTestingT(t)
+
+	throttles, err := NewResponseThrottleListFromView([]v2.ResponseThrottleView{
+		{UrlPattern: "example.com/download", HttpMethod: "GET", BytesPerSecond: 1024},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(throttles.GetThrottle(RequestDetails{Destination: "example.com", Path: "/download", Method: "GET"}).BytesPerSecond).To(Equal(1024))
+	Expect(throttles.GetThrottle(RequestDetails{Destination: "example.com", Path: "/download", Method: "POST"})).To(BeNil())
+
+	Expect(throttles.ConvertToResponseThrottleViews()).To(Equal([]v2.ResponseThrottleView{
+		{UrlPattern: "example.com/download", HttpMethod: "GET", BytesPerSecond: 1024},
+	}))
+}
+
+func TestNewResponseThrottleListFromView_ReturnsAnErrorForInvalidThrottles(t *testing.T) {
+	RegisterTestingT(t)
+
+	invalid := []v2.ResponseThrottleView{
+		{UrlPattern: "("},
+		{UrlPattern: ".", BytesPerSecond: -1},
+		{UrlPattern: ".", ChunkDelay: 10},
+	}
+
+	for _, view := range invalid {
+		_, err := NewResponseThrottleListFromView([]v2.ResponseThrottleView{view})
+		Expect(err).ToNot(BeNil(), "%v", view)
+	}
+}
+
+func TestThrottledBody_ReadsAllOfTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	body, err := ioutil.ReadAll(NewThrottledBody(ResponseThrottle{ChunkSize: 3}, []byte("hello world")))
+
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("hello world"))
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
+)
+
+const (
+	WebSocketFromClient = "client"
+	WebSocketFromServer = "server"
+
+	WebSocketText   = "text"
+	WebSocketBinary = "binary"
+)
+
+// WebSocketMessage is a message sent over a WebSocket connection, Time
+// milliseconds after the connection was opened
+type WebSocketMessage struct {
+	From string `json:"from"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+	Time int    `json:"time"`
+	// Matcher - a matcher type, such as regex or glob, which a client text message
+	// is matched with on replay. Without one the message is matched exactly.
+	Matcher string `json:"matcher,omitempty"`
+
+	// invalidData - why the data of a binary message could not be decoded, so
+	// that ValidateWebSocketMessages rejects it
+	invalidData error
+}
+
+// NewWebSocketMessagesFromView converts messages, decoding the data of binary messages
+func NewWebSocketMessagesFromView(views []interfaces.WebSocketMessage) []WebSocketMessage {
+	if len(views) == 0 {
+		return nil
+	}
+
+	messages := make([]WebSocketMessage, len(views))
+	for i, view := range views {
+		data := view.GetData()
+		var invalidData error
+		if view.GetType() == WebSocketBinary {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			data = string(decoded)
+			invalidData = err
+		}
+		messages[i] = WebSocketMessage{From: view.GetFrom(), Type: view.GetType(), Data: data, Time: view.GetTime(), Matcher: view.GetMatcher(), invalidData: invalidData}
+	}
+	return messages
+}
+
+// ValidateWebSocketMessages checks each message has a known sender and type, and
+// that the data of binary messages was valid base64
+func ValidateWebSocketMessages(messages []WebSocketMessage) error {
+	for i, message := range messages {
+		if message.invalidData != nil {
+			return fmt.Errorf("WebSocket message %d is binary but its data is not valid base64: %s", i, message.invalidData.Error())
+		}
+
+		if message.From != WebSocketFromClient && message.From != WebSocketFromServer {
+			return fmt.Errorf("WebSocket message %d must be from %q or %q", i, WebSocketFromClient, WebSocketFromServer)
+		}
+
+		if message.Type != WebSocketText && message.Type != WebSocketBinary {
+			return fmt.Errorf("WebSocket message %d must be of type %q or %q", i, WebSocketText, WebSocketBinary)
+		}
+
+		if message.Time < 0 {
+			return fmt.Errorf("WebSocket message %d cannot have a negative time", i)
+		}
+
+		if message.Matcher != "" && (message.From != WebSocketFromClient || message.Type != WebSocketText) {
+			return fmt.Errorf("WebSocket message %d has a matcher, only client text messages can", i)
+		}
+	}
+	return nil
+}
+
+func convertToWebSocketMessageViews(messages []WebSocketMessage) []v1.WebSocketMessageView {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	views := make([]v1.WebSocketMessageView, len(messages))
+	for i, message := range messages {
+		data := message.Data
+		if message.Type == WebSocketBinary {
+			data = base64.StdEncoding.EncodeToString([]byte(data))
+		}
+		views[i] = v1.WebSocketMessageView{From: message.From, Type: message.Type, Data: data, Time: message.Time, Matcher: message.Matcher}
+	}
+	return views
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestResponseDetails_ConvertToResponseDetailsView_EncodesBinaryWebSocketMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := ResponseDetails{
+		Status: 101,
+		WebSocketMessages: []WebSocketMessage{
+			{From: WebSocketFromServer, Type: WebSocketText, Data: "welcome", Time: 0},
+			{From: WebSocketFromClient, Type: WebSocketBinary, Data: "\x00\x01", Time: 12},
+		},
+	}
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.WebSocketMessages).To(HaveLen(2))
+	Expect(view.WebSocketMessages[0].Data).To(Equal("welcome"))
+	Expect(view.WebSocketMessages[1].Data).To(Equal("AAE="))
+
+	Expect(NewResponseDetailsFromResponse(view).WebSocketMessages).To(Equal(response.WebSocketMessages))
+}
+
+func TestValidateWebSocketMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ValidateWebSocketMessages([]WebSocketMessage{{From: "server", Type: "text"}})).To(BeNil())
+	Expect(ValidateWebSocketMessages([]WebSocketMessage{{From: "proxy", Type: "text"}})).ToNot(BeNil())
+	Expect(ValidateWebSocketMessages([]WebSocketMessage{{From: "client", Type: "ping"}})).ToNot(BeNil())
+	Expect(ValidateWebSocketMessages([]WebSocketMessage{{From: "client", Type: "text", Time: -1}})).ToNot(BeNil())
+}
+
+func TestValidateWebSocketMessages_RejectsBinaryMessagesWhichAreNotBase64(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := NewResponseDetailsFromResponse(v2.ResponseDetailsView{
+		Status: 101,
+		WebSocketMessages: []v1.WebSocketMessageView{
+			{From: WebSocketFromServer, Type: WebSocketBinary, Data: "AAE="},
+		},
+	})
+	Expect(ValidateWebSocketMessages(response.WebSocketMessages)).To(BeNil())
+
+	response = NewResponseDetailsFromResponse(v2.ResponseDetailsView{
+		Status: 101,
+		WebSocketMessages: []v1.WebSocketMessageView{
+			{From: WebSocketFromServer, Type: WebSocketBinary, Data: "not base64!"},
+		},
+	})
+	err := ValidateWebSocketMessages(response.WebSocketMessages)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("WebSocket message 0 is binary but its data is not valid base64"))
+}
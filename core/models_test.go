@@ -136,7 +136,7 @@ func TestMatchOnRequestBody(t *testing.T) {
 		requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
 		Expect(err).To(BeNil())
 
-		response, err := dbClient.getResponse(request, requestDetails)
+		response, _, err := dbClient.getResponse(request, requestDetails)
 		Expect(err).To(BeNil())
 
 		responseBody, err := ioutil.ReadAll(response.Body)
@@ -161,7 +161,7 @@ func TestGetNotRecordedRequest(t *testing.T) {
 	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
 	Expect(err).To(BeNil())
 
-	response, err := dbClient.getResponse(request, requestDetails)
+	response, _, err := dbClient.getResponse(request, requestDetails)
 	Expect(err).ToNot(BeNil())
 
 	Expect(response).To(BeNil())
@@ -355,7 +355,7 @@ func TestGetResponseCorruptedRequestResponsePair(t *testing.T) {
 	requestDetails, err := models.NewRequestDetailsFromHttpRequest(reqNew)
 	Expect(err).To(BeNil())
 
-	response, err := dbClient.getResponse(reqNew, requestDetails)
+	response, _, err := dbClient.getResponse(reqNew, requestDetails)
 	Expect(err).ToNot(BeNil())
 
 	Expect(response).To(BeNil())
@@ -0,0 +1,99 @@
+package hoverfly
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	// PassthroughInclude - matching requests are processed by Hoverfly
+	PassthroughInclude = "include"
+	// PassthroughExclude - matching requests are proxied to their destination untouched
+	PassthroughExclude = "exclude"
+)
+
+// PassthroughRule includes or excludes the requests matching all of its host
+// glob, path prefix and method, leaving out any that are empty. The first rule
+// a request matches decides, requests matching none are processed.
+type PassthroughRule struct {
+	Action     string
+	Host       string
+	PathPrefix string
+	Method     string
+}
+
+func NewPassthroughRule(action, host, pathPrefix, method string) (PassthroughRule, error) {
+	if action != PassthroughInclude && action != PassthroughExclude {
+		return PassthroughRule{}, fmt.Errorf("Passthrough rule action must be either '%s' or '%s'", PassthroughInclude, PassthroughExclude)
+	}
+
+	if _, err := path.Match(host, ""); err != nil {
+		return PassthroughRule{}, fmt.Errorf("Passthrough rule host %s is not a valid glob", host)
+	}
+
+	return PassthroughRule{
+		Action:     action,
+		Host:       strings.ToLower(host),
+		PathPrefix: pathPrefix,
+		Method:     strings.ToUpper(method),
+	}, nil
+}
+
+func (this PassthroughRule) matchesHost(host string) bool {
+	if this.Host == "" {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	if matched, _ := path.Match(this.Host, host); matched {
+		return true
+	}
+	// a glob without a port matches the host on any port
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		return false
+	}
+	matched, _ := path.Match(this.Host, hostname)
+	return matched
+}
+
+func (this PassthroughRule) matches(req *http.Request) bool {
+	host := req.URL.Host
+	if host == "" {
+		host = req.Host
+	}
+
+	return this.matchesHost(host) &&
+		strings.HasPrefix(req.URL.Path, this.PathPrefix) &&
+		(this.Method == "" || this.Method == req.Method)
+}
+
+// passesThrough returns true when the request is excluded from processing
+func (hf *Hoverfly) passesThrough(req *http.Request) bool {
+	for _, rule := range hf.Cfg.GetPassthroughRules() {
+		if rule.matches(req) {
+			return rule.Action == PassthroughExclude
+		}
+	}
+	return false
+}
+
+// passesThroughHost returns true when every request to the host is excluded, so
+// its HTTPS traffic does not need to be intercepted
+func (hf *Hoverfly) passesThroughHost(host string) bool {
+	for _, rule := range hf.Cfg.GetPassthroughRules() {
+		if !rule.matchesHost(host) {
+			continue
+		}
+		if rule.Action == PassthroughInclude {
+			return false
+		}
+		if rule.PathPrefix == "" && rule.Method == "" {
+			return true
+		}
+	}
+	return false
+}
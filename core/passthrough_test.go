@@ -0,0 +1,94 @@
+package hoverfly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNewPassthroughRule_RejectsBadActionsAndGlobs(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewPassthroughRule(PassthroughExclude, "*.example.com", "/health", "get")
+	Expect(err).To(BeNil())
+
+	_, err = NewPassthroughRule("ignore", "", "", "")
+	Expect(err).ToNot(BeNil())
+
+	_, err = NewPassthroughRule(PassthroughExclude, "[example", "", "")
+	Expect(err).ToNot(BeNil())
+}
+
+func TestHoverfly_PassthroughRulesAreMatchedInOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.SetPassthroughRules(v2.PassthroughView{Rules: []v2.PassthroughRuleView{
+		{Action: PassthroughExclude, Host: "beacons.io"},
+		{Action: PassthroughInclude, PathPrefix: "/health/deep"},
+		{Action: PassthroughExclude, PathPrefix: "/health"},
+		{Action: PassthroughExclude, Host: "*.analytics.com", Method: "post"},
+	}})).To(BeNil())
+
+	passesThrough := func(method, target string) bool {
+		req, _ := http.NewRequest(method, target, nil)
+		return unit.passesThrough(req)
+	}
+
+	Expect(passesThrough("GET", "http://api.example.com/health")).To(BeTrue())
+	Expect(passesThrough("GET", "http://api.example.com/health/deep")).To(BeFalse())
+	Expect(passesThrough("GET", "http://api.example.com/users")).To(BeFalse())
+	Expect(passesThrough("POST", "http://eu.analytics.com:8080/collect")).To(BeTrue())
+	Expect(passesThrough("GET", "http://eu.analytics.com/collect")).To(BeFalse())
+
+	Expect(unit.passesThroughHost("beacons.io:443")).To(BeTrue())
+	// an earlier rule includes some of its requests
+	Expect(unit.passesThroughHost("eu.analytics.com:443")).To(BeFalse())
+	Expect(unit.passesThroughHost("api.example.com:443")).To(BeFalse())
+}
+
+func TestHoverfly_ExcludedRequestsAreNotCaptured(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+	unit.HTTP = GetDefaultHoverflyHTTPClient(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(proxy.URL)
+		},
+	}}
+
+	Expect(unit.SetPassthroughRules(v2.PassthroughView{Rules: []v2.PassthroughRuleView{
+		{Action: PassthroughExclude, PathPrefix: "/health"},
+	}})).To(BeNil())
+	unit.Cfg.SetMode(CaptureMode)
+
+	for _, path := range []string{"/health", "/users"} {
+		resp, err := client.Get(upstream.URL + path)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp.Body.Close()
+	}
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(*simulation.RequestResponsePairs[0].Request.Path).To(Equal("/users"))
+}
@@ -2,10 +2,11 @@ package hoverfly
 
 import (
 	"bufio"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"regexp"
-	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/rusenask/goproxy"
@@ -18,7 +19,7 @@ func NewProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	proxy := goproxy.NewProxyHttpServer()
 
 	proxy.OnRequest(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).
-		HandleConnect(goproxy.AlwaysMitm)
+		HandleConnect(mitmConnect(hoverfly, proxy))
 
 	// enable curl -p for all hosts on port 80
 	proxy.OnRequest(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).
@@ -50,7 +51,25 @@ func NewProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	// processing connections
 	proxy.OnRequest(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).DoFunc(
 		func(r *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+			if hoverfly.passesThrough(r) {
+				// proxied as it is
+				return r, nil
+			}
+
+			writer := clientWriterFor(r)
+			if writer != nil && hoverfly.handlesWebSocket(r) {
+				if resp := hoverfly.processWebSocket(writer, r); resp != nil {
+					return r, resp
+				}
+				// the connection has been taken over, this response is discarded
+				return r, goproxy.NewResponse(r, goproxy.ContentTypeText, http.StatusSwitchingProtocols, "")
+			}
+
 			resp := hoverfly.processRequest(r)
+			if writer != nil && !injectConnectionFault(writer, resp) {
+				writer.streaming = isStreamed(resp.Body)
+				writer.trailer = resp.Trailer
+			}
 			return r, resp
 		})
 
@@ -71,10 +90,15 @@ func NewProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	// intercepts response
 	proxy.OnResponse(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).DoFunc(
 		func(resp *http.Response, ctx *goproxy.ProxyCtx) *http.Response {
-			hoverfly.Counter.Count(hoverfly.Cfg.GetMode())
+			if !hoverfly.passesThrough(ctx.Req) {
+				hoverfly.Counter.Count(hoverfly.routeFor(ctx.Req).Mode)
+			}
 			return resp
 		})
 
+	// cleartext HTTP/2 clients send their requests as they would to the server
+	proxy.NonproxyHandler = withH2C(proxy)
+
 	proxy.Verbose = hoverfly.Cfg.Verbose
 	// proxy starting message
 	log.WithFields(log.Fields{
@@ -93,18 +117,40 @@ func NewWebserverProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	proxy := goproxy.NewProxyHttpServer()
 	proxy.NonproxyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Warn("NonproxyHandler")
-		resp := hoverfly.processRequest(r)
-		body, err := extractBody(resp)
-
-		if err != nil {
-			log.Error("Error reading response body")
-			w.WriteHeader(500)
+		var resp *http.Response
+		if hoverfly.handlesWebSocket(r) {
+			if resp = hoverfly.processWebSocket(w, r); resp == nil {
+				return
+			}
+		} else {
+			resp = hoverfly.processRequest(r)
+		}
+		if injectConnectionFault(w, resp) {
 			return
 		}
 
-		for name, values := range resp.Header {
-			name = strings.ToLower(name)
+		if writer := clientWriterFor(r); writer != nil {
+			writer.trailer = resp.Trailer
+		}
+
+		// a throttled or streamed response is written to the client as it is read
+		streamed := isStreamed(resp.Body)
+
+		var body []byte
+		if !streamed {
+			// a truncated response is passed on as far as it goes
+			var err error
+			body, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if err != nil && err != io.ErrUnexpectedEOF {
+				log.Error("Error reading response body")
+				w.WriteHeader(500)
+				return
+			}
+		}
 
+		for name, values := range resp.Header {
 			for _, value := range values {
 				w.Header().Add(name, value)
 			}
@@ -114,6 +160,15 @@ func NewWebserverProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 		w.Header().Set("Resp", resp.Header.Get("Content-Length"))
 
 		w.WriteHeader(resp.StatusCode)
+		if streamed {
+			if err := streamBody(w, resp.Body); err != nil {
+				log.WithFields(log.Fields{
+					"error": err.Error(),
+				}).Warn("Failed to stream response body")
+			}
+			resp.Body.Close()
+			return
+		}
 		w.Write(body)
 	})
 
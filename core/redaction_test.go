@@ -0,0 +1,130 @@
+package hoverfly
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func TestHoverfly_RedactsCapturedPairsBeforeStoringThem(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{"token": "abc123"}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	Expect(unit.SetRedaction(v2.RedactionView{
+		MaskHeaders:   []string{"Authorization"},
+		MaskBodyPaths: []string{"$.token"},
+	})).To(BeNil())
+
+	req, _ := http.NewRequest("POST", "http://somehost.com/login", bytes.NewBufferString("user=hoverfly"))
+	req.Header.Set("Authorization", "Bearer secret")
+
+	unit.Cfg.SetMode(CaptureMode)
+	resp := unit.processRequest(req)
+	Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+	records, err := unit.RequestCache.GetAllEntries()
+	Expect(err).To(BeNil())
+	Expect(records).To(HaveLen(1))
+	for _, record := range records {
+		pair, err := models.NewRequestResponsePairFromBytes(record)
+		Expect(err).To(BeNil())
+		Expect(pair.Request.Headers["Authorization"]).To(Equal([]string{"REDACTED"}))
+		Expect(pair.Response.Body).To(MatchJSON(`{"token": "REDACTED"}`))
+	}
+}
+
+func TestHoverfly_RedactsPairsWhenExporting(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	pair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Method:      "GET",
+			Destination: "somehost.com",
+			Path:        "/user",
+			Headers:     map[string][]string{"Cookie": []string{"session=1"}},
+		},
+		Response: models.ResponseDetails{Status: 200, Body: "email: a@b.com"},
+	}
+	Expect(unit.RequestMatcher.SaveRequestResponsePair(&pair)).To(BeNil())
+
+	Expect(unit.SetRedaction(v2.RedactionView{
+		DropHeaders:      []string{"Cookie"},
+		BodyReplacements: []v2.BodyReplacementView{{Pattern: `\S+@\S+`, Replacement: "[email]"}},
+	})).To(BeNil())
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(simulation.RequestResponsePairs[0].Request.Headers).ToNot(HaveKey("Cookie"))
+	Expect(simulation.RequestResponsePairs[0].Response.Body).To(Equal("email: [email]"))
+}
+
+func TestHoverfly_SimulatesCapturedRequestsWhoseBodiesWereRedacted(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	Expect(unit.SetRedaction(v2.RedactionView{
+		MaskBodyPaths: []string{"$.password"},
+	})).To(BeNil())
+
+	login := func(password string) *http.Request {
+		req, _ := http.NewRequest("POST", "http://somehost.com/login", bytes.NewBufferString(`{"user": "hoverfly", "password": "`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	unit.Cfg.SetMode(CaptureMode)
+	Expect(unit.processRequest(login("secret")).StatusCode).To(Equal(http.StatusCreated))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(*simulation.RequestResponsePairs[0].Request.Body).To(MatchJSON(`{"user": "hoverfly", "password": "REDACTED"}`))
+
+	unit.Cfg.SetMode(SimulateMode)
+	Expect(unit.processRequest(login("secret")).StatusCode).To(Equal(http.StatusCreated))
+	Expect(unit.processRequest(login("another")).StatusCode).To(Equal(http.StatusCreated))
+}
+
+func TestHoverfly_SimulatesRequestsWhoseBodiesWereRedactedAfterARestart(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	Expect(unit.SetRedaction(v2.RedactionView{
+		MaskBodyPaths: []string{"$.password"},
+	})).To(BeNil())
+
+	login := func() *http.Request {
+		req, _ := http.NewRequest("POST", "http://somehost.com/login", bytes.NewBufferString(`{"user": "hoverfly", "password": "secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	unit.Cfg.SetMode(CaptureMode)
+	Expect(unit.processRequest(login()).StatusCode).To(Equal(http.StatusCreated))
+
+	restarted := GetNewHoverfly(unit.Cfg, unit.RequestCache, unit.MetadataCache, nil)
+	restarted.HTTP = unit.HTTP
+
+	Expect(restarted.GetRedaction().MaskBodyPaths).To(ConsistOf("$.password"))
+
+	restarted.Cfg.SetMode(SimulateMode)
+	Expect(restarted.processRequest(login()).StatusCode).To(Equal(http.StatusCreated))
+}
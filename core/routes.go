@@ -0,0 +1,59 @@
+package hoverfly
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Route gives requests to destinations matching its pattern their own mode and
+// middleware. The pattern is matched like the destination regular expression.
+type Route struct {
+	Destination string
+	Mode        string
+	Middleware  string
+
+	pattern *regexp.Regexp
+}
+
+func NewRoute(destination, mode, middleware string) (Route, error) {
+	pattern, err := regexp.Compile(destination)
+	if err != nil {
+		return Route{}, fmt.Errorf("Route destination %s is not a valid regular expression string", destination)
+	}
+
+	if !availableModes[mode] {
+		return Route{}, fmt.Errorf("Route for %s has an invalid mode: %s", destination, mode)
+	}
+
+	return Route{
+		Destination: destination,
+		Mode:        mode,
+		Middleware:  middleware,
+		pattern:     pattern,
+	}, nil
+}
+
+func (this Route) matches(req *http.Request) bool {
+	host := req.URL.Host
+	if host == "" {
+		host = req.Host
+	}
+	return this.pattern.MatchString(req.URL.Path) || this.pattern.MatchString(host+req.URL.Path)
+}
+
+// routeFor returns the first route matching the request, or one with the global
+// mode and middleware when none do
+func (hf *Hoverfly) routeFor(req *http.Request) Route {
+	for _, route := range hf.Cfg.GetRoutes() {
+		if route.matches(req) {
+			return route
+		}
+	}
+
+	return Route{
+		Destination: hf.Cfg.Destination,
+		Mode:        hf.Cfg.GetMode(),
+		Middleware:  hf.Cfg.Middleware,
+	}
+}
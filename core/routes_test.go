@@ -0,0 +1,63 @@
+package hoverfly
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNewRoute_RejectsBadPatternsAndModes(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewRoute("payments.internal", CaptureMode, "")
+	Expect(err).To(BeNil())
+
+	_, err = NewRoute("(payments", CaptureMode, "")
+	Expect(err).ToNot(BeNil())
+
+	_, err = NewRoute("payments.internal", "record", "")
+	Expect(err).ToNot(BeNil())
+}
+
+func TestHoverfly_RoutesEachDestinationToItsOwnMode(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	unit.Cfg.SetMode(SimulateMode)
+	Expect(unit.SetRoutes(v2.RoutesView{Routes: []v2.RouteView{
+		{Destination: "payments.internal", Mode: CaptureMode},
+	}})).To(BeNil())
+	Expect(unit.GetRoutes().Routes).To(ConsistOf(v2.RouteView{Destination: "payments.internal", Mode: CaptureMode}))
+
+	payments, _ := http.NewRequest("GET", "http://payments.internal/charge", nil)
+	ledger, _ := http.NewRequest("GET", "http://ledger.internal/balance", nil)
+
+	Expect(unit.routeFor(payments).Mode).To(Equal(CaptureMode))
+	Expect(unit.routeFor(ledger).Mode).To(Equal(SimulateMode))
+
+	// captured as its route says
+	Expect(unit.processRequest(payments).StatusCode).To(Equal(http.StatusCreated))
+	// simulated, and there is nothing recorded for it
+	Expect(unit.processRequest(ledger).StatusCode).To(Equal(http.StatusPreconditionFailed))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(*simulation.RequestResponsePairs[0].Request.Destination).To(Equal("payments.internal"))
+}
+
+func TestHoverfly_SetRoutesRejectsAnInvalidRoute(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := unit.SetRoutes(v2.RoutesView{Routes: []v2.RouteView{{Destination: "payments.internal", Mode: "record"}}})
+	Expect(err).ToNot(BeNil())
+	Expect(unit.GetRoutes().Routes).To(BeEmpty())
+}
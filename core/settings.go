@@ -18,6 +18,26 @@ type Configuration struct {
 	DatabasePath string
 	Webserver    bool
 
+	// CaptureSequence - when set, repeated requests in capture mode are recorded
+	// as a sequence of responses instead of overwriting each other
+	CaptureSequence string
+
+	// Routes - give requests to destinations matching a pattern their own mode
+	// and middleware, requests to anywhere else use Mode and Middleware
+	Routes []Route
+
+	// PassthroughRules - decide, before anything else, whether a request is
+	// processed or proxied as it is
+	PassthroughRules []PassthroughRule
+
+	// SpyCapture - when set, requests spy mode forwards to their destination are
+	// captured as well
+	SpyCapture bool
+
+	// StreamSpeed - how much faster than recorded the events of a streamed response
+	// are replayed in simulate mode
+	StreamSpeed float64
+
 	TLSVerification bool
 
 	Verbose     bool
@@ -39,6 +59,36 @@ func (c *Configuration) SetMode(mode string) {
 	c.mu.Unlock()
 }
 
+// SetRoutes - provides safe way to replace the routes
+func (c *Configuration) SetRoutes(routes []Route) {
+	c.mu.Lock()
+	c.Routes = routes
+	c.mu.Unlock()
+}
+
+// GetRoutes - provides safe way to get the current routes
+func (c *Configuration) GetRoutes() []Route {
+	c.mu.Lock()
+	routes := c.Routes
+	c.mu.Unlock()
+	return routes
+}
+
+// SetPassthroughRules - provides safe way to replace the passthrough rules
+func (c *Configuration) SetPassthroughRules(rules []PassthroughRule) {
+	c.mu.Lock()
+	c.PassthroughRules = rules
+	c.mu.Unlock()
+}
+
+// GetPassthroughRules - provides safe way to get the current passthrough rules
+func (c *Configuration) GetPassthroughRules() []PassthroughRule {
+	c.mu.Lock()
+	rules := c.PassthroughRules
+	c.mu.Unlock()
+	return rules
+}
+
 // GetMode - provides safe way to get current mode
 func (c *Configuration) GetMode() string {
 	c.mu.Lock()
@@ -47,6 +97,14 @@ func (c *Configuration) GetMode() string {
 	return mode
 }
 
+// Ways of replaying a captured sequence of responses once the last one is reached
+const (
+	// CaptureSequenceLoop - start again from the first response
+	CaptureSequenceLoop = "loop"
+	// CaptureSequenceStick - keep returning the last response
+	CaptureSequenceStick = "stick"
+)
+
 // DefaultPort - default proxy port
 const DefaultPort = "8500"
 
@@ -57,6 +115,9 @@ const DefaultAdminPort = "8888"
 // or used by Hoverfly
 const DefaultDatabasePath = "requests.db"
 
+// DefaultStreamSpeed - streamed responses are replayed with their recorded timing
+const DefaultStreamSpeed = 1
+
 // DefaultJWTExpirationDelta - default token expiration if environment variable is no provided
 const DefaultJWTExpirationDelta = 1 * 24 * 60 * 60
 
@@ -78,6 +139,10 @@ const (
 	HoverflyAdminPasswordEV = "HoverflyAdminPass"
 
 	HoverflyImportRecordsEV = "HoverflyImport"
+
+	HoverflyCaptureSequenceEV = "HoverflyCaptureSequence"
+	HoverflyStreamSpeedEV     = "HoverflyStreamSpeed"
+	HoverflySpyCaptureEV      = "HoverflySpyCapture"
 )
 
 // InitSettings gets and returns initial configuration from env
@@ -143,5 +208,20 @@ func InitSettings() *Configuration {
 		appConfig.TLSVerification = true
 	}
 
+	appConfig.CaptureSequence = os.Getenv(HoverflyCaptureSequenceEV)
+
+	appConfig.SpyCapture = os.Getenv(HoverflySpyCaptureEV) == "true"
+
+	appConfig.StreamSpeed = DefaultStreamSpeed
+	if os.Getenv(HoverflyStreamSpeedEV) != "" {
+		speed, err := strconv.ParseFloat(os.Getenv(HoverflyStreamSpeedEV), 64)
+		if err != nil || speed <= 0 {
+			log.WithFields(log.Fields{
+				"HoverflyStreamSpeed": os.Getenv(HoverflyStreamSpeedEV),
+			}).Fatal("stream speed must be a number greater than 0")
+		}
+		appConfig.StreamSpeed = speed
+	}
+
 	return &appConfig
 }
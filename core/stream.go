@@ -0,0 +1,138 @@
+package hoverfly
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// maxRecordedStreamSize is how much of a stream is recorded. A stream which goes
+// on for longer is saved as it was up to there, and passed on without being recorded.
+var maxRecordedStreamSize = 10 * 1024 * 1024
+
+// isStreamingResponse returns true for server-sent events and for chunked responses
+// without a length, either of which may never end
+func isStreamingResponse(resp *http.Response) bool {
+	if isEventStream(resp) {
+		return true
+	}
+	return resp.ContentLength == -1 && len(resp.TransferEncoding) > 0 && resp.TransferEncoding[0] == "chunked"
+}
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// recordingBody passes a stream on to the client as it arrives. A stream of
+// server-sent events is also kept as events, split on the blank line ending each
+// of them, with the time it arrived. Once the stream ends, the client goes away
+// or maxRecordedStreamSize is reached, the response is handed to done.
+type recordingBody struct {
+	body     io.ReadCloser
+	start    time.Time
+	split    bool
+	pending  []byte
+	all      bytes.Buffer
+	events   []models.ResponseEvent
+	done     func(body []byte, events []models.ResponseEvent)
+	once     sync.Once
+	finished bool
+}
+
+func newRecordingBody(resp *http.Response, done func(body []byte, events []models.ResponseEvent)) *recordingBody {
+	return &recordingBody{
+		body:  resp.Body,
+		start: time.Now(),
+		split: isEventStream(resp),
+		done:  done,
+	}
+}
+
+func (this *recordingBody) Read(p []byte) (int, error) {
+	n, err := this.body.Read(p)
+	if n > 0 {
+		this.record(p[:n])
+	}
+	if err != nil {
+		// goproxy does not close the bodies of responses sent over MITM connections
+		this.finish()
+	}
+	return n, err
+}
+
+func (this *recordingBody) Close() error {
+	this.finish()
+	return this.body.Close()
+}
+
+func (this *recordingBody) record(data []byte) {
+	if this.finished {
+		return
+	}
+
+	full := false
+	if left := maxRecordedStreamSize - this.all.Len(); len(data) >= left {
+		data = data[:left]
+		full = true
+	}
+	this.all.Write(data)
+
+	if this.split {
+		this.splitEvents(data)
+	}
+
+	if full {
+		log.WithFields(log.Fields{
+			"size": maxRecordedStreamSize,
+		}).Warn("Stream is too long to record, saving it as it was so far")
+		this.finish()
+	}
+}
+
+func (this *recordingBody) splitEvents(data []byte) {
+	this.pending = append(this.pending, data...)
+	for {
+		end := eventEnd(this.pending)
+		if end == -1 {
+			return
+		}
+		this.addEvent(this.pending[:end])
+		this.pending = this.pending[end:]
+	}
+}
+
+func (this *recordingBody) addEvent(data []byte) {
+	this.events = append(this.events, models.ResponseEvent{
+		Time: int(time.Since(this.start) / time.Millisecond),
+		Data: string(data),
+	})
+}
+
+func (this *recordingBody) finish() {
+	this.once.Do(func() {
+		this.finished = true
+		if len(this.pending) > 0 {
+			this.addEvent(this.pending)
+			this.pending = nil
+		}
+		this.done(this.all.Bytes(), this.events)
+	})
+}
+
+// eventEnd returns the index just after the blank line ending the first
+// server-sent event in data, or -1 if the event is not complete yet
+func eventEnd(data []byte) int {
+	end := -1
+	for _, separator := range []string{"\n\n", "\r\n\r\n"} {
+		if i := bytes.Index(data, []byte(separator)); i != -1 && (end == -1 || i+len(separator) < end) {
+			end = i + len(separator)
+		}
+	}
+	return end
+}
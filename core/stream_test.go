@@ -0,0 +1,218 @@
+package hoverfly
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+type chunkedReader struct {
+	chunks []string
+}
+
+func (this *chunkedReader) Read(p []byte) (int, error) {
+	if len(this.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, this.chunks[0])
+	this.chunks = this.chunks[1:]
+	return n, nil
+}
+
+func (this *chunkedReader) Close() error { return nil }
+
+func eventStreamServer(events ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		for _, event := range events {
+			fmt.Fprint(w, event)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+}
+
+func TestIsStreamingResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	eventStream := &http.Response{Header: http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}}, ContentLength: -1}
+	chunked := &http.Response{Header: http.Header{}, ContentLength: -1, TransferEncoding: []string{"chunked"}}
+	plain := &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}, ContentLength: 10}
+
+	Expect(isStreamingResponse(eventStream)).To(BeTrue())
+	Expect(isStreamingResponse(chunked)).To(BeTrue())
+	Expect(isStreamingResponse(plain)).To(BeFalse())
+}
+
+func TestRecordingBody_SplitsServerSentEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	var saved []models.ResponseEvent
+	var savedBody []byte
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:   &chunkedReader{chunks: []string{"data: one\n", "\ndata: two\r\n\r\nda", "ta: three"}},
+	}
+
+	body := newRecordingBody(resp, func(respBody []byte, events []models.ResponseEvent) {
+		savedBody = respBody
+		saved = events
+	})
+	ioutil.ReadAll(body)
+	body.Close()
+
+	Expect(string(savedBody)).To(Equal("data: one\n\ndata: two\r\n\r\ndata: three"))
+	Expect(saved).To(HaveLen(3))
+	Expect(saved[0].Data).To(Equal("data: one\n\n"))
+	Expect(saved[1].Data).To(Equal("data: two\r\n\r\n"))
+	Expect(saved[2].Data).To(Equal("data: three"))
+}
+
+func TestRecordingBody_KeepsOtherStreamsWhole(t *testing.T) {
+	RegisterTestingT(t)
+
+	var saved []models.ResponseEvent
+	var savedBody []byte
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body:   &chunkedReader{chunks: []string{`{"items": [`, "\n\n", `1]}`}},
+	}
+
+	body := newRecordingBody(resp, func(respBody []byte, events []models.ResponseEvent) {
+		savedBody = respBody
+		saved = events
+	})
+	ioutil.ReadAll(body)
+	body.Close()
+
+	Expect(string(savedBody)).To(Equal("{\"items\": [\n\n1]}"))
+	Expect(saved).To(BeEmpty())
+}
+
+func TestRecordingBody_SavesStreamsWhichAreTooLongToRecordAsTheyWereSoFar(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer func(size int) { maxRecordedStreamSize = size }(maxRecordedStreamSize)
+	maxRecordedStreamSize = 20
+
+	var saved []models.ResponseEvent
+	var savedBody []byte
+	calls := 0
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:   &chunkedReader{chunks: []string{"data: one\n\n", "data: two\n\n", "data: three\n\n"}},
+	}
+
+	body := newRecordingBody(resp, func(respBody []byte, events []models.ResponseEvent) {
+		savedBody = respBody
+		saved = events
+		calls++
+	})
+
+	read := make([]byte, 0)
+	buffer := make([]byte, 64)
+	for i := 0; i < 2; i++ {
+		n, err := body.Read(buffer)
+		Expect(err).To(BeNil())
+		read = append(read, buffer[:n]...)
+	}
+	Expect(calls).To(Equal(1))
+	Expect(string(savedBody)).To(Equal("data: one\n\ndata: two"))
+	Expect(saved).To(HaveLen(2))
+	Expect(saved[0].Data).To(Equal("data: one\n\n"))
+	Expect(saved[1].Data).To(Equal("data: two"))
+
+	rest, err := ioutil.ReadAll(body)
+	Expect(err).To(BeNil())
+	body.Close()
+
+	Expect(string(read) + string(rest)).To(Equal("data: one\n\ndata: two\n\ndata: three\n\n"))
+	Expect(calls).To(Equal(1))
+}
+
+func TestHoverfly_CapturesAndReplaysServerSentEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	upstream := eventStreamServer("data: one\n\n", "data: two\n\n", "data: three\n\n")
+	defer upstream.Close()
+
+	unit.HTTP = &http.Client{}
+	unit.Cfg.SetMode("capture")
+
+	r, _ := http.NewRequest("GET", upstream.URL+"/events", nil)
+	response := unit.processRequest(r)
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("data: one\n\ndata: two\n\ndata: three\n\n"))
+	response.Body.Close()
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+
+	events := simulation.RequestResponsePairs[0].Response.Events
+	Expect(events).To(HaveLen(3))
+	Expect(events[2].Data).To(Equal("data: three\n\n"))
+	Expect(events[2].Time).To(BeNumerically(">=", 200))
+
+	unit.Cfg.SetMode("simulate")
+	unit.Cfg.StreamSpeed = 4
+
+	r, _ = http.NewRequest("GET", upstream.URL+"/events", nil)
+	start := time.Now()
+	response = unit.processRequest(r)
+
+	body, err = ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(strings.Count(string(body), "data:")).To(Equal(3))
+	Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+	Expect(time.Since(start)).To(BeNumerically("<", 150*time.Millisecond))
+}
+
+func TestHoverfly_CapturesChunkedResponsesWhichAreNotEventStreamsAsAWhole(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		fmt.Fprint(w, `{"items": [`)
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, `1, 2]}`)
+	}))
+	defer upstream.Close()
+
+	unit.HTTP = &http.Client{}
+	unit.Cfg.SetMode("capture")
+
+	r, _ := http.NewRequest("GET", upstream.URL+"/items", nil)
+	response := unit.processRequest(r)
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal(`{"items": [1, 2]}`))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(simulation.RequestResponsePairs[0].Response.Body).To(Equal(`{"items": [1, 2]}`))
+	Expect(simulation.RequestResponsePairs[0].Response.Events).To(BeEmpty())
+}
@@ -0,0 +1,201 @@
+// Package templating renders response bodies and headers which reference the request
+// they are responding to, using text/template. For example:
+//
+//	{"id": "{{ pathSegment 1 }}", "requestId": "{{ header "X-Request-Id" }}", "at": "{{ now }}"}
+package templating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pborman/uuid"
+)
+
+const randomStringCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Request is the request data available to a template as .Request
+type Request struct {
+	Scheme       string
+	Destination  string
+	Method       string
+	Path         string
+	PathSegments []string
+	Query        map[string][]string
+	Headers      map[string][]string
+	Body         string
+}
+
+type templateData struct {
+	Request Request
+}
+
+// RenderResponse returns the response with its body and header values rendered as templates
+func RenderResponse(response models.ResponseDetails, request models.RequestDetails) (models.ResponseDetails, error) {
+	data := templateData{Request: newRequest(request)}
+	functions := newFunctions(data.Request)
+
+	body, err := render("body", response.Body, data, functions)
+	if err != nil {
+		return response, err
+	}
+	response.Body = body
+
+	headers := map[string][]string{}
+	for name, values := range response.Headers {
+		for _, value := range values {
+			rendered, err := render("header "+name, value, data, functions)
+			if err != nil {
+				return response, err
+			}
+			headers[name] = append(headers[name], rendered)
+		}
+	}
+
+	// the recorded length is of the body before it was rendered
+	for name := range headers {
+		if http.CanonicalHeaderKey(name) == "Content-Length" {
+			headers[name] = []string{strconv.Itoa(len(body))}
+		}
+	}
+	response.Headers = headers
+
+	return response, nil
+}
+
+// Validate checks that the body and header values of a response can be parsed as templates
+func Validate(response models.ResponseDetails) error {
+	functions := newFunctions(Request{})
+
+	if _, err := template.New("body").Funcs(functions).Parse(response.Body); err != nil {
+		return err
+	}
+	for name, values := range response.Headers {
+		for _, value := range values {
+			if _, err := template.New("header " + name).Funcs(functions).Parse(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func render(name, text string, data templateData, functions template.FuncMap) (string, error) {
+	parsed, err := template.New(name).Funcs(functions).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := parsed.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func newRequest(request models.RequestDetails) Request {
+	var segments []string
+	for _, segment := range strings.Split(request.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return Request{
+		Scheme:       request.Scheme,
+		Destination:  request.Destination,
+		Method:       request.Method,
+		Path:         request.Path,
+		PathSegments: segments,
+		Query:        models.ParseQuery(request.Query),
+		Headers:      request.Headers,
+		Body:         request.Body,
+	}
+}
+
+func newFunctions(request Request) template.FuncMap {
+	return template.FuncMap{
+		// pathSegment returns a segment of the path, counting from 0, or nothing
+		"pathSegment": func(index int) string {
+			if index < 0 || index >= len(request.PathSegments) {
+				return ""
+			}
+			return request.PathSegments[index]
+		},
+		// query returns the first value of a query parameter
+		"query": func(name string) string {
+			if values := request.Query[name]; len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		},
+		// header returns the first value of a request header
+		"header": func(name string) string {
+			for headerName, values := range request.Headers {
+				if strings.EqualFold(headerName, name) && len(values) > 0 {
+					return values[0]
+				}
+			}
+			return ""
+		},
+		// jsonPath evaluates a JSONPath expression against the request body, nothing
+		// is returned when the expression does not find anything
+		"jsonPath": func(expression string) (string, error) {
+			return jsonPathLookup(expression, request.Body)
+		},
+		// now returns the current time, as RFC3339 unless a Go time layout is given
+		"now": func(layout ...string) string {
+			if len(layout) > 0 {
+				return time.Now().Format(layout[0])
+			}
+			return time.Now().Format(time.RFC3339)
+		},
+		"uuid": func() string {
+			return uuid.New()
+		},
+		// randomInt returns a number from min up to and including max
+		"randomInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("randomInt: %d is less than %d", max, min)
+			}
+			return min + rand.Intn(max-min+1), nil
+		},
+		"randomString": func(length int) string {
+			characters := make([]byte, length)
+			for i := range characters {
+				characters[i] = randomStringCharacters[rand.Intn(len(randomStringCharacters))]
+			}
+			return string(characters)
+		},
+	}
+}
+
+func jsonPathLookup(expression, body string) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return "", fmt.Errorf("jsonPath: request body is not JSON")
+	}
+
+	result, err := jsonpath.JsonPathLookup(data, expression)
+	if err != nil {
+		return "", nil
+	}
+
+	if value, ok := result.(string); ok {
+		return value, nil
+	}
+
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprint(result), nil
+	}
+	return string(bytes), nil
+}
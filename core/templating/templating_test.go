@@ -0,0 +1,110 @@
+package templating
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var request = models.RequestDetails{
+	Scheme:      "http",
+	Destination: "test.com",
+	Method:      "POST",
+	Path:        "/orders/123/items",
+	Query:       "page=2&tag=a&tag=b",
+	Headers:     map[string][]string{"X-Request-Id": []string{"abc"}},
+	Body:        `{"order": {"id": 123, "customer": "bob"}}`,
+}
+
+func renderBody(text string) string {
+	response, err := RenderResponse(models.ResponseDetails{Body: text}, request)
+	Expect(err).To(BeNil())
+	return response.Body
+}
+
+func TestRenderResponse_RendersRequestFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(renderBody("{{ .Request.Method }} {{ .Request.Scheme }}://{{ .Request.Destination }}{{ .Request.Path }}")).To(Equal("POST http://test.com/orders/123/items"))
+	Expect(renderBody(`{{ index .Request.Query "tag" }}`)).To(Equal("[a b]"))
+}
+
+func TestRenderResponse_RendersPathSegments(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(renderBody("{{ pathSegment 1 }}")).To(Equal("123"))
+	Expect(renderBody("{{ index .Request.PathSegments 0 }}")).To(Equal("orders"))
+	Expect(renderBody("{{ pathSegment 9 }}")).To(Equal(""))
+}
+
+func TestRenderResponse_RendersQueryParamsAndHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(renderBody(`{{ query "page" }}-{{ query "tag" }}-{{ query "missing" }}`)).To(Equal("2-a-"))
+	Expect(renderBody(`{{ header "x-request-id" }}`)).To(Equal("abc"))
+}
+
+func TestRenderResponse_RendersJSONPathIntoTheRequestBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(renderBody(`{{ jsonPath "$.order.customer" }}`)).To(Equal("bob"))
+	Expect(renderBody(`{{ jsonPath "$.order.id" }}`)).To(Equal("123"))
+	Expect(renderBody(`{{ jsonPath "$.order.missing" }}`)).To(Equal(""))
+}
+
+func TestRenderResponse_RendersHelpers(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(renderBody(`{{ now "2006" }}`)).To(Equal(strconv.Itoa(time.Now().Year())))
+	Expect(renderBody("{{ uuid }}")).To(MatchRegexp("^[0-9a-f-]{36}$"))
+	Expect(renderBody("{{ randomString 12 }}")).To(MatchRegexp("^[a-zA-Z0-9]{12}$"))
+
+	number, err := strconv.Atoi(renderBody("{{ randomInt 5 7 }}"))
+	Expect(err).To(BeNil())
+	Expect(number).To(BeNumerically(">=", 5))
+	Expect(number).To(BeNumerically("<=", 7))
+}
+
+func TestRenderResponse_RendersHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	response, err := RenderResponse(models.ResponseDetails{
+		Status:  201,
+		Headers: map[string][]string{"Location": []string{"/orders/{{ pathSegment 1 }}"}},
+	}, request)
+
+	Expect(err).To(BeNil())
+	Expect(response.Status).To(Equal(201))
+	Expect(response.Headers["Location"]).To(Equal([]string{"/orders/123"}))
+}
+
+func TestRenderResponse_UpdatesTheContentLengthToTheRenderedBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := "order {{ pathSegment 1 }}"
+	response, err := RenderResponse(models.ResponseDetails{
+		Body:    body,
+		Headers: map[string][]string{"Content-Length": []string{strconv.Itoa(len(body))}},
+	}, request)
+
+	Expect(err).To(BeNil())
+	Expect(response.Body).To(Equal("order 123"))
+	Expect(response.Headers["Content-Length"]).To(Equal([]string{"9"}))
+}
+
+func TestRenderResponse_ReturnsAnErrorWhenTheTemplateFails(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := RenderResponse(models.ResponseDetails{Body: "{{ randomInt 7 5 }}"}, request)
+	Expect(err).ToNot(BeNil())
+}
+
+func TestValidate_ReturnsAnErrorForInvalidTemplates(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(Validate(models.ResponseDetails{Body: "{{ pathSegment 1 }}"})).To(BeNil())
+	Expect(Validate(models.ResponseDetails{Body: "{{ unknown }}"})).ToNot(BeNil())
+	Expect(Validate(models.ResponseDetails{Headers: map[string][]string{"A": []string{"{{ .Request"}}})).ToNot(BeNil())
+}
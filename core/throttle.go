@@ -0,0 +1,28 @@
+package hoverfly
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// throttleResponse replays the body of the response at the pace of the throttle.
+// Responses cut short by a fault cannot be read and are left as they are.
+func throttleResponse(response *http.Response, throttle *models.ResponseThrottle) *http.Response {
+	// streamed responses keep their own pace
+	if isStreamed(response.Body) {
+		return response
+	}
+
+	body, err := extractBody(response)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Warn("Response will not be throttled")
+		return response
+	}
+
+	response.Body = models.NewThrottledBody(*throttle, body)
+	return response
+}
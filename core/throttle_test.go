@@ -0,0 +1,86 @@
+package hoverfly
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	. "github.com/onsi/gomega"
+)
+
+func throttledSimulation(throttle v2.ResponseThrottleView) v2.SimulationView {
+	simulation := faultySimulation(nil)
+	simulation.GlobalActions.Throttles = []v2.ResponseThrottleView{throttle}
+	return simulation
+}
+
+func TestThrottleResponse_StreamsChunksFromTheWebserver(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutSimulation(throttledSimulation(v2.ResponseThrottleView{
+		UrlPattern:      ".",
+		TimeToFirstByte: 50,
+		ChunkSize:       10,
+		ChunkDelay:      50,
+	}))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+	unit.Cfg.Webserver = true
+
+	webserver := httptest.NewServer(withClientWriter(NewWebserverProxy(unit)))
+	defer webserver.Close()
+
+	start := time.Now()
+	response, err := http.Get(webserver.URL + "/faulty")
+	Expect(err).To(BeNil())
+
+	firstChunk := make([]byte, 10)
+	_, err = io.ReadFull(response.Body, firstChunk)
+	Expect(err).To(BeNil())
+	firstChunkAt := time.Since(start)
+
+	rest, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(firstChunk) + string(rest)).To(Equal("a body that will not arrive in one piece"))
+
+	Expect(firstChunkAt).To(BeNumerically(">=", 50*time.Millisecond))
+	Expect(time.Since(start) - firstChunkAt).To(BeNumerically(">=", 150*time.Millisecond))
+}
+
+func TestThrottleResponse_LimitsTheBandwidthThroughTheProxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutSimulation(throttledSimulation(v2.ResponseThrottleView{
+		UrlPattern:     "test.com",
+		BytesPerSecond: 200,
+	}))).To(BeNil())
+	unit.Cfg.SetMode("simulate")
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(proxy.URL)
+		},
+	}}
+
+	start := time.Now()
+	response, err := client.Get("http://test.com/faulty")
+	Expect(err).To(BeNil())
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("a body that will not arrive in one piece"))
+	Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+}
@@ -4,6 +4,10 @@ func StringToPointer(value string) *string {
 	return &value
 }
 
+func IntToPointer(value int) *int {
+	return &value
+}
+
 func PointerToString(value *string) string {
 	if value == nil {
 		return ""
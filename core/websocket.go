@@ -0,0 +1,311 @@
+package hoverfly
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketHandshakeHeaders are set on each hop of the handshake by the
+// WebSocket library rather than being passed on
+var webSocketHandshakeHeaders = []string{
+	"Upgrade",
+	"Connection",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Accept",
+	"Sec-Websocket-Extensions",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+}
+
+func isWebSocketUpgrade(req *http.Request) bool {
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	for _, value := range req.Header["Connection"] {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handlesWebSocket returns true when the request is a WebSocket upgrade that is
+// recorded or simulated rather than processed as a plain request
+func (hf *Hoverfly) handlesWebSocket(req *http.Request) bool {
+	mode := hf.routeFor(req).Mode
+	return isWebSocketUpgrade(req) && (mode == CaptureMode || mode == SimulateMode)
+}
+
+// processWebSocket records or simulates a WebSocket connection. It returns nil once
+// it has taken over the connection, or a response to send in place of the upgrade.
+// Middleware is not applied to WebSocket messages.
+func (hf *Hoverfly) processWebSocket(w http.ResponseWriter, req *http.Request) *http.Response {
+	if hf.routeFor(req).Mode == CaptureMode {
+		return hf.captureWebSocket(w, req)
+	}
+	return hf.simulateWebSocket(w, req)
+}
+
+// captureWebSocket connects the client to the destination, relaying and recording
+// messages in both directions, and saves the exchange when the connection closes
+func (hf *Hoverfly) captureWebSocket(w http.ResponseWriter, req *http.Request) *http.Response {
+	scheme := "ws"
+	if req.URL.Scheme == "https" {
+		scheme = "wss"
+	}
+
+	dialer := websocket.Dialer{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: hf.Cfg.TLSVerification},
+	}
+
+	server, serverResp, err := dialer.Dial(scheme+"://"+req.Host+req.URL.RequestURI(), withoutHandshakeHeaders(req.Header))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err.Error(),
+			"destination": req.Host,
+			"path":        req.URL.Path,
+			"mode":        CaptureMode,
+		}).Error("Could not open WebSocket to destination")
+
+		return hoverflyError(req, err, "Could not open WebSocket to destination", http.StatusBadGateway)
+	}
+
+	client, err := upgrader.Upgrade(w, req, withoutHandshakeHeaders(serverResp.Header))
+	if err != nil {
+		server.Close()
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"mode":  CaptureMode,
+		}).Error("Failed to upgrade WebSocket connection")
+		return nil
+	}
+
+	recorder := &webSocketRecorder{start: time.Now()}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		recorder.relay(client, server, models.WebSocketFromClient)
+		done <- struct{}{}
+	}()
+	go func() {
+		recorder.relay(server, client, models.WebSocketFromServer)
+		done <- struct{}{}
+	}()
+
+	// once either side closes, the other is closed too
+	<-done
+	client.Close()
+	server.Close()
+	<-done
+
+	log.WithFields(log.Fields{
+		"destination": req.Host,
+		"path":        req.URL.Path,
+		"messages":    len(recorder.messages),
+		"mode":        CaptureMode,
+	}).Info("WebSocket connection captured")
+
+	hf.savePair(req, nil, models.ResponseDetails{
+		Status:            serverResp.StatusCode,
+		Headers:           serverResp.Header,
+		WebSocketMessages: recorder.messages,
+	})
+
+	return nil
+}
+
+// simulateWebSocket answers a matched handshake, then sends the recorded server
+// messages which followed each recorded client message matching the one received
+func (hf *Hoverfly) simulateWebSocket(w http.ResponseWriter, req *http.Request) *http.Response {
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(req)
+	if err != nil {
+		return hoverflyError(req, err, "Could not interpret HTTP request", http.StatusServiceUnavailable)
+	}
+
+	response, matchErr := hf.RequestMatcher.GetResponse(&requestDetails)
+	if matchErr != nil {
+		log.WithFields(log.Fields{
+			"error":       matchErr.Error(),
+			"destination": req.Host,
+			"path":        req.URL.Path,
+			"mode":        SimulateMode,
+		}).Warn("Failed to simulate WebSocket")
+
+		return hoverflyError(req, matchErr, "There was an error when matching", matchErr.StatusCode)
+	}
+
+	if delay := hf.delayFor(requestDetails, response); delay != nil {
+		delay.Execute()
+	}
+
+	if response.Status != http.StatusSwitchingProtocols {
+		// the recorded handshake was refused
+		return NewConstructor(req, models.RequestResponsePair{Request: requestDetails, Response: *response}).ReconstructResponse()
+	}
+
+	client, err := upgrader.Upgrade(w, req, withoutHandshakeHeaders(response.Headers))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"mode":  SimulateMode,
+		}).Error("Failed to upgrade WebSocket connection")
+		return nil
+	}
+	defer client.Close()
+
+	replayer := &webSocketReplayer{messages: response.WebSocketMessages, speed: hf.Cfg.StreamSpeed}
+	if err := replayer.send(client, 0); err != nil {
+		return nil
+	}
+
+	for {
+		kind, data, err := client.ReadMessage()
+		if err != nil {
+			return nil
+		}
+
+		next := replayer.match(kind, data)
+		if next < 0 {
+			log.WithFields(log.Fields{
+				"data": string(data),
+				"mode": SimulateMode,
+			}).Warn("No recorded WebSocket message matched")
+			continue
+		}
+
+		if err := replayer.send(client, next); err != nil {
+			return nil
+		}
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	// the client connects to whichever origin it was proxied to
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func withoutHandshakeHeaders(headers http.Header) http.Header {
+	header := http.Header{}
+	for key, values := range headers {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	for _, key := range webSocketHandshakeHeaders {
+		header.Del(key)
+	}
+	return header
+}
+
+func messageType(messageType int) string {
+	if messageType == websocket.BinaryMessage {
+		return models.WebSocketBinary
+	}
+	return models.WebSocketText
+}
+
+type webSocketRecorder struct {
+	mu       sync.Mutex
+	start    time.Time
+	messages []models.WebSocketMessage
+}
+
+// relay passes messages on until either side fails, forwarding a close
+func (this *webSocketRecorder) relay(from, to *websocket.Conn, sender string) {
+	for {
+		kind, data, err := from.ReadMessage()
+		if err != nil {
+			if closeErr, ok := err.(*websocket.CloseError); ok {
+				to.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(closeErr.Code, closeErr.Text))
+			}
+			return
+		}
+
+		this.mu.Lock()
+		this.messages = append(this.messages, models.WebSocketMessage{
+			From: sender,
+			Type: messageType(kind),
+			Data: string(data),
+			Time: int(time.Since(this.start) / time.Millisecond),
+		})
+		this.mu.Unlock()
+
+		if err := to.WriteMessage(kind, data); err != nil {
+			return
+		}
+	}
+}
+
+type webSocketReplayer struct {
+	messages []models.WebSocketMessage
+	speed    float64
+	// position is the index after the last matched client message
+	position int
+}
+
+// match finds the next recorded client message matching the one received, looking
+// from the last match onwards and then from the start, and returns the index after it
+func (this *webSocketReplayer) match(kind int, data []byte) int {
+	for i := range this.messages {
+		index := (this.position + i) % len(this.messages)
+		message := this.messages[index]
+		if message.From != models.WebSocketFromClient || message.Type != messageType(kind) {
+			continue
+		}
+
+		// recorded messages are matched exactly unless they ask for a matcher
+		matched := message.Data == string(data)
+		if message.Matcher != "" {
+			matcher, err := matching.NewFieldMatcherOfType(message.Matcher, message.Data)
+			matched = err == nil && matcher.Match(string(data))
+		}
+
+		if matched {
+			this.position = index + 1
+			return index + 1
+		}
+	}
+	return -1
+}
+
+// send writes the server messages from index up to the next client message, with
+// their recorded spacing divided by the speed factor
+func (this *webSocketReplayer) send(conn *websocket.Conn, index int) error {
+	speed := this.speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	since := 0
+	if index > 0 {
+		since = this.messages[index-1].Time
+	}
+
+	for ; index < len(this.messages) && this.messages[index].From == models.WebSocketFromServer; index++ {
+		message := this.messages[index]
+		if wait := message.Time - since; wait > 0 {
+			time.Sleep(time.Duration(float64(wait) / speed * float64(time.Millisecond)))
+		}
+		since = message.Time
+
+		kind := websocket.TextMessage
+		if message.Type == models.WebSocketBinary {
+			kind = websocket.BinaryMessage
+		}
+		if err := conn.WriteMessage(kind, []byte(message.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
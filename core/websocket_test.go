@@ -0,0 +1,202 @@
+package hoverfly
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/interfaces"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+// proxiedConn rewrites the request line of a handshake to the absolute form a
+// client sends to a proxy
+type proxiedConn struct {
+	net.Conn
+	target string
+	sent   bool
+}
+
+func (this *proxiedConn) Write(b []byte) (int, error) {
+	if this.sent {
+		return this.Conn.Write(b)
+	}
+
+	this.sent = true
+	if _, err := this.Conn.Write([]byte(strings.Replace(string(b), "GET /", "GET "+this.target+"/", 1))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func dialThroughProxy(proxy *httptest.Server, target string) (*websocket.Conn, *http.Response, error) {
+	dialer := websocket.Dialer{NetDial: func(network, addr string) (net.Conn, error) {
+		conn, err := net.Dial(network, proxy.Listener.Addr().String())
+		return &proxiedConn{Conn: conn, target: target}, err
+	}}
+	return dialer.Dial("ws"+strings.TrimPrefix(target, "http")+"/chat", nil)
+}
+
+func echoWebSocketServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte("welcome"))
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(kind, append([]byte("echo: "), data...))
+		}
+	}))
+}
+
+func expectWebSocketMessage(conn *websocket.Conn, kind int, data string) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	actualKind, actual, err := conn.ReadMessage()
+	Expect(err).To(BeNil())
+	Expect(actualKind).To(Equal(kind))
+	Expect(string(actual)).To(Equal(data))
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	RegisterTestingT(t)
+
+	upgrade, _ := http.NewRequest("GET", "http://test.com/chat", nil)
+	upgrade.Header.Set("Upgrade", "WebSocket")
+	upgrade.Header.Set("Connection", "keep-alive, Upgrade")
+
+	plain, _ := http.NewRequest("GET", "http://test.com/chat", nil)
+	plain.Header.Set("Connection", "keep-alive")
+
+	Expect(isWebSocketUpgrade(upgrade)).To(BeTrue())
+	Expect(isWebSocketUpgrade(plain)).To(BeFalse())
+}
+
+func TestWebSocketReplayer_MatchesRecordedMessagesExactly(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &webSocketReplayer{messages: []models.WebSocketMessage{
+		{From: models.WebSocketFromClient, Type: models.WebSocketText, Data: "price * 2"},
+		{From: models.WebSocketFromClient, Type: models.WebSocketText, Data: `^subscribe [a-z]+$`, Matcher: "regex"},
+	}}
+
+	Expect(unit.match(websocket.TextMessage, []byte("price * 2"))).To(Equal(1))
+	Expect(unit.match(websocket.TextMessage, []byte("price of 2"))).To(Equal(-1))
+	Expect(unit.match(websocket.TextMessage, []byte("subscribe orders"))).To(Equal(2))
+	Expect(unit.match(websocket.TextMessage, []byte("^subscribe [a-z]+$"))).To(Equal(-1))
+}
+
+func TestHoverfly_CapturesAndSimulatesWebSocketsThroughTheProxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	upstream := echoWebSocketServer()
+	defer upstream.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	unit.Cfg.SetMode("capture")
+
+	conn, _, err := dialThroughProxy(proxy, upstream.URL)
+	Expect(err).To(BeNil())
+
+	expectWebSocketMessage(conn, websocket.TextMessage, "welcome")
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte("hello"))).To(BeNil())
+	expectWebSocketMessage(conn, websocket.TextMessage, "echo: hello")
+	Expect(conn.WriteMessage(websocket.BinaryMessage, []byte{0, 1})).To(BeNil())
+	expectWebSocketMessage(conn, websocket.BinaryMessage, "echo: \x00\x01")
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+
+	Eventually(func() int {
+		simulation, _ := unit.GetSimulation()
+		return len(simulation.RequestResponsePairs)
+	}).Should(Equal(1))
+
+	simulation, err := unit.GetSimulation()
+	Expect(err).To(BeNil())
+
+	response := simulation.RequestResponsePairs[0].Response
+	Expect(response.Status).To(Equal(http.StatusSwitchingProtocols))
+	Expect(response.WebSocketMessages).To(HaveLen(5))
+	Expect(response.WebSocketMessages[1].From).To(Equal("client"))
+	Expect(response.WebSocketMessages[1].Data).To(Equal("hello"))
+	Expect(response.WebSocketMessages[4].From).To(Equal("server"))
+	Expect(response.WebSocketMessages[4].Type).To(Equal("binary"))
+	Expect(response.WebSocketMessages[4].Data).To(Equal("ZWNobzogAAE="))
+
+	unit.Cfg.SetMode("simulate")
+	target := upstream.URL
+	upstream.Close()
+
+	conn, _, err = dialThroughProxy(proxy, target)
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	expectWebSocketMessage(conn, websocket.TextMessage, "welcome")
+	Expect(conn.WriteMessage(websocket.BinaryMessage, []byte{0, 1})).To(BeNil())
+	expectWebSocketMessage(conn, websocket.BinaryMessage, "echo: \x00\x01")
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte("hello"))).To(BeNil())
+	expectWebSocketMessage(conn, websocket.TextMessage, "echo: hello")
+}
+
+func TestHoverfly_ImportRejectsBinaryWebSocketMessagesWhichAreNotBase64(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+	defer unit.RequestCache.DeleteData()
+
+	pair := v1.RequestResponsePairView{
+		Request: v1.RequestDetailsView{
+			Path:        util.StringToPointer("/socket"),
+			Method:      util.StringToPointer("GET"),
+			Destination: util.StringToPointer("somehost.com"),
+		},
+		Response: v1.ResponseDetailsView{
+			Status: 101,
+			WebSocketMessages: []v1.WebSocketMessageView{
+				{From: models.WebSocketFromServer, Type: models.WebSocketBinary, Data: "not base64!"},
+			},
+		},
+	}
+
+	err := unit.ImportRequestResponsePairViews([]interfaces.RequestResponsePair{pair})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("not valid base64"))
+
+	count, _ := unit.RequestCache.RecordsCount()
+	Expect(count).To(Equal(0))
+}
+
+func TestHoverfly_SimulateWebSocketRefusesAnUnmatchedHandshake(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	proxy := httptest.NewServer(withClientWriter(NewProxy(unit)))
+	defer proxy.Close()
+
+	unit.Cfg.SetMode("simulate")
+
+	_, resp, err := dialThroughProxy(proxy, "http://test.com")
+	Expect(err).To(Equal(websocket.ErrBadHandshake))
+	Expect(resp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
@@ -25,6 +26,12 @@ const (
 	v2ApiMode        = "/api/v2/hoverfly/mode"
 	v2ApiDestination = "/api/v2/hoverfly/destination"
 	v2ApiMiddleware  = "/api/v2/hoverfly/middleware"
+	v2ApiHashing     = "/api/v2/hoverfly/hashing"
+	v2ApiRoutes      = "/api/v2/hoverfly/routes"
+	v2ApiPassthrough = "/api/v2/hoverfly/passthrough"
+	v2ApiRedaction   = "/api/v2/hoverfly/redaction"
+	v2ApiScenarios   = "/api/v2/scenarios"
+	v2ApiDiff        = "/api/v2/diff"
 )
 
 type APIStateSchema struct {
@@ -37,9 +44,17 @@ type APIDelaySchema struct {
 }
 
 type ResponseDelaySchema struct {
-	UrlPattern string `json:"urlpattern"`
-	Delay      int    `json:"delay"`
-	HttpMethod string `json:"httpmethod"`
+	UrlPattern   string                 `json:"urlpattern"`
+	Delay        int                    `json:"delay"`
+	HttpMethod   string                 `json:"httpmethod"`
+	Distribution string                 `json:"distribution,omitempty"`
+	Min          int                    `json:"min,omitempty"`
+	Max          int                    `json:"max,omitempty"`
+	Mean         int                    `json:"mean,omitempty"`
+	StdDev       int                    `json:"stdDev,omitempty"`
+	Median       int                    `json:"median,omitempty"`
+	P99          int                    `json:"p99,omitempty"`
+	Request      map[string]interface{} `json:"request,omitempty"`
 }
 
 type HoverflyAuthSchema struct {
@@ -55,6 +70,85 @@ type MiddlewareSchema struct {
 	Middleware string `json:"middleware"`
 }
 
+type HashingSchema struct {
+	IncludeQueryParams []string `json:"includeQueryParams,omitempty"`
+	ExcludeQueryParams []string `json:"excludeQueryParams,omitempty"`
+	IncludeHeaders     []string `json:"includeHeaders,omitempty"`
+	ExcludeBody        bool     `json:"excludeBody,omitempty"`
+	ExcludeBodyPaths   []string `json:"excludeBodyPaths,omitempty"`
+}
+
+type RoutesSchema struct {
+	Routes []RouteSchema `json:"routes"`
+}
+
+type RouteSchema struct {
+	Destination string `json:"destination"`
+	Mode        string `json:"mode"`
+	Middleware  string `json:"middleware,omitempty"`
+}
+
+type PassthroughSchema struct {
+	Rules []PassthroughRuleSchema `json:"rules"`
+}
+
+type PassthroughRuleSchema struct {
+	Action     string `json:"action"`
+	Host       string `json:"host,omitempty"`
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	Method     string `json:"method,omitempty"`
+}
+
+type RedactionSchema struct {
+	Mask             string                  `json:"mask,omitempty"`
+	DropHeaders      []string                `json:"dropHeaders,omitempty"`
+	MaskHeaders      []string                `json:"maskHeaders,omitempty"`
+	BodyReplacements []BodyReplacementSchema `json:"bodyReplacements,omitempty"`
+	MaskBodyPaths    []string                `json:"maskBodyPaths,omitempty"`
+}
+
+type BodyReplacementSchema struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type ScenariosSchema struct {
+	Scenarios []ScenarioSchema `json:"scenarios"`
+}
+
+type ScenarioSchema struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type DiffSchema struct {
+	Diffs []ResponseDiffSchema `json:"diffs"`
+}
+
+type ResponseDiffSchema struct {
+	Request     DiffRequestSchema  `json:"request"`
+	Time        string             `json:"time"`
+	Differences []DifferenceSchema `json:"differences"`
+}
+
+type DiffRequestSchema struct {
+	Method      string `json:"method"`
+	Scheme      string `json:"scheme"`
+	Destination string `json:"destination"`
+	Path        string `json:"path"`
+	Query       string `json:"query"`
+}
+
+type DifferenceSchema struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type MessageSchema struct {
+	Message string `json:"message"`
+}
+
 type ErrorSchema struct {
 	ErrorMessage string `json:"error"`
 }
@@ -144,7 +238,7 @@ func (h *Hoverfly) GetMode() (string, error) {
 
 // Set will go the state endpoint in Hoverfly, sending JSON that will set the mode of Hoverfly
 func (h *Hoverfly) SetMode(mode string) (string, error) {
-	if mode != "simulate" && mode != "capture" && mode != "modify" && mode != "synthesize" {
+	if mode != "simulate" && mode != "capture" && mode != "modify" && mode != "synthesize" && mode != "spy" && mode != "diff" {
 		return "", errors.New(mode + " is not a valid mode")
 	}
 
@@ -591,6 +685,363 @@ func (h *Hoverfly) stop(hoverflyDirectory HoverflyDirectory) error {
 	return nil
 }
 
+// GetHashing will go to the hashing endpoint in Hoverfly and return which parts of a request are hashed
+func (h *Hoverfly) GetHashing() (HashingSchema, error) {
+	var hashing HashingSchema
+
+	slingRequest, err := h.buildGetRequest(v2ApiHashing)
+	if err != nil {
+		return hashing, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return hashing, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return hashing, errors.New("Could not read the hashing configuration from Hoverfly")
+	}
+
+	err = json.Unmarshal(body, &hashing)
+	if err != nil {
+		log.Debug(err.Error())
+		return hashing, errors.New("Could not read the hashing configuration from Hoverfly")
+	}
+
+	return hashing, nil
+}
+
+// SetHashing will send the hashing configuration in the JSON file to Hoverfly
+func (h *Hoverfly) SetHashing(path string) (HashingSchema, error) {
+	conf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return HashingSchema{}, err
+	}
+
+	slingRequest, err := h.buildPutRequest(v2ApiHashing, string(conf))
+	if err != nil {
+		return HashingSchema{}, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return HashingSchema{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(response.Body)
+
+		error := &ErrorSchema{}
+		json.Unmarshal(body, error)
+
+		return HashingSchema{}, errors.New("Hashing configuration was not set in Hoverfly: " + error.ErrorMessage)
+	}
+
+	return h.GetHashing()
+}
+
+// GetRoutes will go to the routes endpoint in Hoverfly and return the mode and middleware of each routed destination
+func (h *Hoverfly) GetRoutes() (RoutesSchema, error) {
+	var routes RoutesSchema
+
+	slingRequest, err := h.buildGetRequest(v2ApiRoutes)
+	if err != nil {
+		return routes, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return routes, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return routes, errors.New("Could not read the routes from Hoverfly")
+	}
+
+	err = json.Unmarshal(body, &routes)
+	if err != nil {
+		log.Debug(err.Error())
+		return routes, errors.New("Could not read the routes from Hoverfly")
+	}
+
+	return routes, nil
+}
+
+// SetRoutes will send the routes in the JSON file to Hoverfly
+func (h *Hoverfly) SetRoutes(path string) (RoutesSchema, error) {
+	conf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RoutesSchema{}, err
+	}
+
+	slingRequest, err := h.buildPutRequest(v2ApiRoutes, string(conf))
+	if err != nil {
+		return RoutesSchema{}, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return RoutesSchema{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(response.Body)
+
+		error := &ErrorSchema{}
+		json.Unmarshal(body, error)
+
+		return RoutesSchema{}, errors.New("Routes were not set in Hoverfly: " + error.ErrorMessage)
+	}
+
+	return h.GetRoutes()
+}
+
+// GetPassthrough will go to the passthrough endpoint in Hoverfly and return the rules deciding which requests are processed
+func (h *Hoverfly) GetPassthrough() (PassthroughSchema, error) {
+	var passthrough PassthroughSchema
+
+	slingRequest, err := h.buildGetRequest(v2ApiPassthrough)
+	if err != nil {
+		return passthrough, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return passthrough, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return passthrough, errors.New("Could not read the passthrough rules from Hoverfly")
+	}
+
+	err = json.Unmarshal(body, &passthrough)
+	if err != nil {
+		log.Debug(err.Error())
+		return passthrough, errors.New("Could not read the passthrough rules from Hoverfly")
+	}
+
+	return passthrough, nil
+}
+
+// SetPassthrough will send the passthrough rules in the JSON file to Hoverfly
+func (h *Hoverfly) SetPassthrough(path string) (PassthroughSchema, error) {
+	conf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PassthroughSchema{}, err
+	}
+
+	slingRequest, err := h.buildPutRequest(v2ApiPassthrough, string(conf))
+	if err != nil {
+		return PassthroughSchema{}, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return PassthroughSchema{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(response.Body)
+
+		error := &ErrorSchema{}
+		json.Unmarshal(body, error)
+
+		return PassthroughSchema{}, errors.New("Passthrough rules were not set in Hoverfly: " + error.ErrorMessage)
+	}
+
+	return h.GetPassthrough()
+}
+
+// GetRedaction will go to the redaction endpoint in Hoverfly and return what is removed from recordings
+func (h *Hoverfly) GetRedaction() (RedactionSchema, error) {
+	var redaction RedactionSchema
+
+	slingRequest, err := h.buildGetRequest(v2ApiRedaction)
+	if err != nil {
+		return redaction, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return redaction, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return redaction, errors.New("Could not read the redaction configuration from Hoverfly")
+	}
+
+	err = json.Unmarshal(body, &redaction)
+	if err != nil {
+		log.Debug(err.Error())
+		return redaction, errors.New("Could not read the redaction configuration from Hoverfly")
+	}
+
+	return redaction, nil
+}
+
+// SetRedaction will send the redaction configuration in the JSON file to Hoverfly
+func (h *Hoverfly) SetRedaction(path string) (RedactionSchema, error) {
+	conf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RedactionSchema{}, err
+	}
+
+	slingRequest, err := h.buildPutRequest(v2ApiRedaction, string(conf))
+	if err != nil {
+		return RedactionSchema{}, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return RedactionSchema{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(response.Body)
+
+		error := &ErrorSchema{}
+		json.Unmarshal(body, error)
+
+		return RedactionSchema{}, errors.New("Redaction configuration was not set in Hoverfly: " + error.ErrorMessage)
+	}
+
+	return h.GetRedaction()
+}
+
+// GetScenarios will go to the scenarios endpoint in Hoverfly and return the state of each scenario
+func (h *Hoverfly) GetScenarios() ([]ScenarioSchema, error) {
+	slingRequest, err := h.buildGetRequest(v2ApiScenarios)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.createScenariosSchema(response)
+}
+
+// ResetScenarios will put a scenario, or every scenario when no name is given, back into its started state
+func (h *Hoverfly) ResetScenarios(name string) ([]ScenarioSchema, error) {
+	endpoint := v2ApiScenarios
+	if name != "" {
+		endpoint = endpoint + "/" + url.PathEscape(name)
+	}
+
+	slingRequest, err := h.buildDeleteRequest(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == 404 {
+		return nil, errors.New("Scenario " + name + " does not exist in Hoverfly")
+	}
+
+	return h.createScenariosSchema(response)
+}
+
+func (h *Hoverfly) createScenariosSchema(response *http.Response) ([]ScenarioSchema, error) {
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, errors.New("Could not read the scenarios from Hoverfly")
+	}
+
+	var scenarios ScenariosSchema
+
+	err = json.Unmarshal(body, &scenarios)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, errors.New("Could not read the scenarios from Hoverfly")
+	}
+
+	return scenarios.Scenarios, nil
+}
+
+// GetDiff will go to the diff endpoint in Hoverfly and return the differences diff mode has found
+func (h *Hoverfly) GetDiff() ([]ResponseDiffSchema, error) {
+	slingRequest, err := h.buildGetRequest(v2ApiDiff)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.createDiffSchema(response)
+}
+
+// ClearDiff will delete the differences diff mode has found
+func (h *Hoverfly) ClearDiff() ([]ResponseDiffSchema, error) {
+	slingRequest, err := h.buildDeleteRequest(v2ApiDiff)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.doRequest(slingRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.createDiffSchema(response)
+}
+
+func (h *Hoverfly) createDiffSchema(response *http.Response) ([]ResponseDiffSchema, error) {
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, errors.New("Could not read the diff from Hoverfly")
+	}
+
+	var diff DiffSchema
+
+	err = json.Unmarshal(body, &diff)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, errors.New("Could not read the diff from Hoverfly")
+	}
+
+	return diff.Diffs, nil
+}
+
 // GetMode will go the state endpoint in Hoverfly, parse the JSON response and return the mode of Hoverfly
 func (h *Hoverfly) GetDelays() (rd []ResponseDelaySchema, err error) {
 	slingRequest, err := h.buildGetRequest(v1ApiDelays)
@@ -53,6 +53,25 @@ var (
 	delaysCommand = kingpin.Command("delays", "Get per-host response delay config currently loaded in Hoverfly")
 	delaysPathArg = delaysCommand.Arg("path", "Set per-host response delay config from JSON file").String()
 
+	hashingCommand = kingpin.Command("hashing", "Get which parts of a request Hoverfly uses to store and look up recorded requests")
+	hashingPathArg = hashingCommand.Arg("path", "Set the hashing configuration from JSON file").String()
+
+	routesCommand = kingpin.Command("routes", "Get the mode and middleware each destination with its own is routed to")
+	routesPathArg = routesCommand.Arg("path", "Set the routes from JSON file").String()
+
+	passthroughCommand = kingpin.Command("passthrough", "Get the rules deciding which requests Hoverfly processes and which it proxies untouched")
+	passthroughPathArg = passthroughCommand.Arg("path", "Set the passthrough rules from JSON file").String()
+
+	redactionCommand = kingpin.Command("redaction", "Get which headers and body content Hoverfly removes from recordings")
+	redactionPathArg = redactionCommand.Arg("path", "Set the redaction configuration from JSON file").String()
+
+	scenariosCommand   = kingpin.Command("scenarios", "Get the current state of each scenario in Hoverfly")
+	scenariosActionArg = scenariosCommand.Arg("action", "Use reset to put scenarios back into their started state").String()
+	scenariosNameArg   = scenariosCommand.Arg("name", "The name of a single scenario to reset").String()
+
+	diffCommand   = kingpin.Command("diff", "Get the differences between real responses and the simulation found in diff mode")
+	diffActionArg = diffCommand.Arg("action", "Use clear to delete the differences found so far").String()
+
 	logsCommand    = kingpin.Command("logs", "Get the logs from Hoverfly")
 	followLogsFlag = logsCommand.Flag("follow", "Follow the logs from Hoverfly").Bool()
 
@@ -246,6 +265,123 @@ func main() {
 			log.Info("Response delays set in Hoverfly: ")
 			printResponseDelays(delays)
 		}
+	case hashingCommand.FullCommand():
+		var hashing HashingSchema
+		var err error
+		if *hashingPathArg == "" || *hashingPathArg == "status" {
+			hashing, err = hoverfly.GetHashing()
+			handleIfError(err)
+		} else {
+			hashing, err = hoverfly.SetHashing(*hashingPathArg)
+			handleIfError(err)
+			fmt.Println("Hashing configuration set in Hoverfly: ")
+		}
+		hashingJson, err := json.MarshalIndent(hashing, "", "    ")
+		if err != nil {
+			log.Error("Error marshalling JSON for printing hashing configuration: " + err.Error())
+		}
+		fmt.Println(string(hashingJson))
+	case routesCommand.FullCommand():
+		var routes RoutesSchema
+		var err error
+		if *routesPathArg == "" || *routesPathArg == "status" {
+			routes, err = hoverfly.GetRoutes()
+			handleIfError(err)
+		} else {
+			routes, err = hoverfly.SetRoutes(*routesPathArg)
+			handleIfError(err)
+			fmt.Println("Routes set in Hoverfly: ")
+		}
+		routesJson, err := json.MarshalIndent(routes, "", "    ")
+		if err != nil {
+			log.Error("Error marshalling JSON for printing routes: " + err.Error())
+		}
+		fmt.Println(string(routesJson))
+	case passthroughCommand.FullCommand():
+		var passthrough PassthroughSchema
+		var err error
+		if *passthroughPathArg == "" || *passthroughPathArg == "status" {
+			passthrough, err = hoverfly.GetPassthrough()
+			handleIfError(err)
+		} else {
+			passthrough, err = hoverfly.SetPassthrough(*passthroughPathArg)
+			handleIfError(err)
+			fmt.Println("Passthrough rules set in Hoverfly: ")
+		}
+		passthroughJson, err := json.MarshalIndent(passthrough, "", "    ")
+		if err != nil {
+			log.Error("Error marshalling JSON for printing passthrough rules: " + err.Error())
+		}
+		fmt.Println(string(passthroughJson))
+	case redactionCommand.FullCommand():
+		var redaction RedactionSchema
+		var err error
+		if *redactionPathArg == "" || *redactionPathArg == "status" {
+			redaction, err = hoverfly.GetRedaction()
+			handleIfError(err)
+		} else {
+			redaction, err = hoverfly.SetRedaction(*redactionPathArg)
+			handleIfError(err)
+			fmt.Println("Redaction configuration set in Hoverfly: ")
+		}
+		redactionJson, err := json.MarshalIndent(redaction, "", "    ")
+		if err != nil {
+			log.Error("Error marshalling JSON for printing redaction configuration: " + err.Error())
+		}
+		fmt.Println(string(redactionJson))
+	case scenariosCommand.FullCommand():
+		var scenarios []ScenarioSchema
+		var err error
+		switch *scenariosActionArg {
+		case "", "status":
+			scenarios, err = hoverfly.GetScenarios()
+			handleIfError(err)
+		case "reset":
+			scenarios, err = hoverfly.ResetScenarios(*scenariosNameArg)
+			handleIfError(err)
+			if *scenariosNameArg == "" {
+				log.Info("Every scenario has been reset in Hoverfly")
+			} else {
+				log.Info("Scenario " + *scenariosNameArg + " has been reset in Hoverfly")
+			}
+		default:
+			handleIfError(errors.New("You have not specified a valid action for scenarios"))
+		}
+
+		if len(scenarios) == 0 {
+			log.Info("Hoverfly has no scenarios")
+		}
+		for _, scenario := range scenarios {
+			log.Info(fmt.Sprintf("%v - %v", scenario.Name, scenario.State))
+		}
+	case diffCommand.FullCommand():
+		var diffs []ResponseDiffSchema
+		var err error
+		switch *diffActionArg {
+		case "":
+			diffs, err = hoverfly.GetDiff()
+			handleIfError(err)
+		case "clear":
+			diffs, err = hoverfly.ClearDiff()
+			handleIfError(err)
+			log.Info("Differences have been cleared in Hoverfly")
+		default:
+			handleIfError(errors.New("You have not specified a valid action for diff"))
+		}
+
+		if len(diffs) == 0 {
+			log.Info("Hoverfly has found no differences")
+		}
+		for _, diff := range diffs {
+			target := diff.Request.Scheme + "://" + diff.Request.Destination + diff.Request.Path
+			if diff.Request.Query != "" {
+				target = target + "?" + diff.Request.Query
+			}
+			log.Info(fmt.Sprintf("%v %v at %v", diff.Request.Method, target, diff.Time))
+			for _, difference := range diff.Differences {
+				log.Info(fmt.Sprintf("  %v - expected %v, got %v", difference.Field, difference.Expected, difference.Actual))
+			}
+		}
 	case logsCommand.FullCommand():
 		logfile := NewLogFile(hoverflyDirectory, hoverfly.AdminPort, hoverfly.ProxyPort)
 
@@ -291,14 +427,48 @@ func printResponseDelays(delays []ResponseDelaySchema) {
 	for _, delay := range delays {
 		var delayString string
 		if delay.HttpMethod != "" {
-			delayString = fmt.Sprintf("%v | %v - %vms", delay.HttpMethod, delay.UrlPattern, delay.Delay)
+			delayString = fmt.Sprintf("%v | %v - %v", delay.HttpMethod, delay.UrlPattern, describeDelay(delay))
 		} else {
-			delayString = fmt.Sprintf("%v - %vms", delay.UrlPattern, delay.Delay)
+			delayString = fmt.Sprintf("%v - %v", delay.UrlPattern, describeDelay(delay))
 		}
 		log.Info(delayString)
 	}
 }
 
+func describeDelay(delay ResponseDelaySchema) string {
+	var description string
+	switch delay.Distribution {
+	case "uniform":
+		return fmt.Sprintf("uniform %vms to %vms", delay.Min, delay.Max) + describeDelayRequest(delay)
+	case "normal":
+		description = fmt.Sprintf("normal mean %vms, stdDev %vms", delay.Mean, delay.StdDev)
+	case "lognormal":
+		description = fmt.Sprintf("lognormal median %vms, p99 %vms", delay.Median, delay.P99)
+	default:
+		return fmt.Sprintf("%vms", delay.Delay) + describeDelayRequest(delay)
+	}
+
+	if delay.Min != 0 {
+		description = description + fmt.Sprintf(", min %vms", delay.Min)
+	}
+	if delay.Max != 0 {
+		description = description + fmt.Sprintf(", max %vms", delay.Max)
+	}
+	return description + describeDelayRequest(delay)
+}
+
+func describeDelayRequest(delay ResponseDelaySchema) string {
+	if len(delay.Request) == 0 {
+		return ""
+	}
+
+	request, err := json.Marshal(delay.Request)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(" for requests matching %s", request)
+}
+
 func handleIfError(err error) {
 	if err != nil {
 		log.Fatal(err.Error())
@@ -62,11 +62,20 @@ func (h *Hoverfly) SetRequestTemplates(path string) (responseTemplates *matching
 	url := h.buildURL("/api/templates")
 
 	slingRequest := sling.New().Post(url).Body(strings.NewReader(string(conf)))
-	_, err = h.performAPIRequest(slingRequest)
+	postResponse, err := h.performAPIRequest(slingRequest)
 	if err != nil {
 		return nil, err
 	}
 
+	defer postResponse.Body.Close()
+
+	if postResponse.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(postResponse.Body)
+		var messageView MessageSchema
+		json.Unmarshal(body, &messageView)
+		return nil, errors.New("Request templates were not set in Hoverfly: " + messageView.Message)
+	}
+
 	slingRequest = sling.New().Get(url).Body(strings.NewReader(string(conf)))
 	getResponse, err := h.performAPIRequest(slingRequest)
 	if err != nil {